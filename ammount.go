@@ -6,8 +6,6 @@ import (
 	"net/http"
 	"net/url"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 //Ammount is used to calculate total "sales" and refunds
@@ -18,92 +16,57 @@ type Ammount struct {
 }
 
 //AmmountToday return the total ammount of charges for the past week
+//
+//Deprecated: use p.Amounts.Today
 func (p *Satis) AmmountToday() (*Ammount, error) {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	amm, err := p.getAmmount(today, now)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.Today()
 }
 
 //AmmountYesterday return the total ammount of charges for the past week
+//
+//Deprecated: use p.Amounts.Yesterday
 func (p *Satis) AmmountYesterday() (*Ammount, error) {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	amm, err := p.getAmmount(today.Add(time.Duration(-24)*time.Hour), today)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.Yesterday()
 }
 
 //AmmountSpecificDate return the total ammount of charges for the past week
+//
+//Deprecated: use p.Amounts.Date
 func (p *Satis) AmmountSpecificDate(year, month, day int) (*Ammount, error) {
-	now := time.Now()
-	prec := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
-	last := prec.Add(24 * time.Hour)
-	amm, err := p.getAmmount(prec, last)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.Date(year, month, day)
 }
 
 //AmmountThisWeek return the total ammount of charges for the past week
+//
+//Deprecated: use p.Amounts.ThisWeek
 func (p *Satis) AmmountThisWeek() (*Ammount, error) {
-	now := time.Now()
-	lastweek := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
-	lastweek = lastweek.Add(time.Duration(-167) * time.Hour)
-	today := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
-	amm, err := p.getAmmount(lastweek, today)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.ThisWeek()
 }
 
-//AmmountThisMonth is cool for accountability
+//AmmountThisMonth returns the totals since the first of the month
+//
+//Deprecated: use p.Amounts.ThisMonth
 func (p *Satis) AmmountThisMonth() (*Ammount, error) {
-	now := time.Now()
-	month := time.Date(now.Year(), now.Month(), 0, 0, 0, 0, 0, now.Location())
-	today := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
-	amm, err := p.getLongAmmount(month, today)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.ThisMonth()
 }
 
-//AmmountThisYear is cool for accountability
+//AmmountThisYear returns the totals since the first of january
+//
+//Deprecated: use p.Amounts.ThisYear
 func (p *Satis) AmmountThisYear() (*Ammount, error) {
-	now := time.Now()
-	prec := time.Date(now.Year(), time.Month(0), 0, 0, 0, 0, 0, now.Location())
-	last := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
-	amm, err := p.getLongAmmount(prec, last)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.ThisYear()
 }
 
-//AmmountSpecificYear is cool for accountability
+//AmmountSpecificYear returns the totals of a year, until today for the current one
+//
+//Deprecated: use p.Amounts.Year
 func (p *Satis) AmmountSpecificYear(year int) (*Ammount, error) {
-	prec := time.Date(year, time.Month(0), 0, 0, 0, 0, 0, time.Now().Location())
-	last := time.Date(year+1, time.Month(0), 0, 0, 0, 0, 0, time.Now().Location())
-	if year == time.Now().Year() {
-		now := time.Now()
-		last = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	}
-	amm, err := p.getLongAmmount(prec, last)
-	if err != nil {
-		return nil, err
-	}
-	return amm, nil
+	return p.Amounts.Year(year)
 }
 
-func (p *Satis) getLongAmmount(start, end time.Time) (*Ammount, error) {
+func (p *Satis) getLongAmmount(start, end time.Time) (_ *Ammount, err error) {
+	p, span := p.startSpan("AmmountRange", attrTime(AttrFrom, start), attrTime(AttrTo, end))
+	defer span.end(&err)
 	if d := end.Sub(start); d.Hours() < 168 {
 		return p.getAmmount(start, end)
 	}
@@ -142,9 +105,9 @@ func (p *Satis) getLongAmmount(start, end time.Time) (*Ammount, error) {
 	return amm, nil
 }
 
-func (p *Satis) getAmmount(start, end time.Time) (*Ammount, error) {
-	color.Red(fmt.Sprint(start))
-	color.Red(fmt.Sprint(end))
+func (p *Satis) getAmmount(start, end time.Time) (_ *Ammount, err error) {
+	p, span := p.startSpan("Ammount", attrTime(AttrFrom, start), attrTime(AttrTo, end))
+	defer span.end(&err)
 	if d := end.Sub(start); d.Hours() > 168 {
 		return nil, fmt.Errorf("Interval is too long")
 	}
@@ -180,7 +143,7 @@ func (p *Satis) getAmmount(start, end time.Time) (*Ammount, error) {
 	//-------------------------- END -----------------------------
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return nil, fmt.Errorf("Error making the call to API: %s", err.Error())
+		return nil, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return nil, fmt.Errorf("Return status is %d:not compatible with the success case", status)
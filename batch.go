@@ -0,0 +1,157 @@
+package satisgo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//BatchOptions configures CreateCharges, zero values take the defaults
+type BatchOptions struct {
+	//Workers is the number of charges created at the same time (8)
+	Workers int
+	//Rate is the number of charges created per second (0 means no limit)
+	Rate float64
+	//BatchID is part of the default idempotency keys: sending the same charges with the same BatchID
+	//does not create them twice, use a new BatchID to really create them again (ex. the id of the event).
+	//It is mandatory unless Key is given
+	BatchID string
+	//Key returns the idempotency key of a charge, by default it is a hash of BatchID and of the charge
+	Key func(c *Charge) string
+}
+
+//BatchResult is the outcome of a charge given to CreateCharges
+type BatchResult struct {
+	//Index is the position of the charge in the list given to CreateCharges
+	Index  int
+	Charge *Charge
+	//IdempotencyKey is the key sent with the charge
+	IdempotencyKey string
+	//AlreadyCreated is true when the charge had an ID and has not been sent
+	AlreadyCreated bool
+	//Err is the reason why the charge has not been created
+	Err error
+}
+
+//BatchResults are the results of CreateCharges, in the order of the charges
+type BatchResults []BatchResult
+
+//Failed returns the charges not created, to give them to CreateCharges again
+func (rs BatchResults) Failed() []*Charge {
+	var list []*Charge
+	for _, r := range rs {
+		if r.Err != nil && r.Charge != nil {
+			list = append(list, r.Charge)
+		}
+	}
+	return list
+}
+
+//batchKey is the default idempotency key of a charge
+func batchKey(batchID string, c *Charge) string {
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(append([]byte(batchID+"\n"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+//CreateCharges creates the charges concurrently, each one with a stable idempotency key.
+//All the charges are validated first: if one is not valid none is sent and the results tell which ones are wrong.
+//The charges are updated in place like CreateCharge does, the ones with an ID are already created and skipped,
+//so the same list (or BatchResults.Failed) can be given again to retry the failures without duplicates.
+//opts.BatchID is required (unless opts.Key is given): two batches with the same BatchID and equal charges
+//share the idempotency keys, so the second one would return the charges of the first one instead of creating them.
+//The error tells how many charges have not been created, or that ctx is done
+func (p *Satis) CreateCharges(ctx context.Context, charges []*Charge, opts BatchOptions) (_ BatchResults, err error) {
+	p, span := p.WithContext(ctx).startSpan("CreateCharges", attrInt(AttrCount, int64(len(charges))))
+	defer span.end(&err)
+	if opts.BatchID == "" && opts.Key == nil {
+		return nil, fmt.Errorf("BatchID cannot be empty")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	results := make(BatchResults, len(charges))
+	keys := make(map[string]int)
+	invalid := 0
+	for i, c := range charges {
+		r := &results[i]
+		r.Index = i
+		r.Charge = c
+		if c == nil {
+			r.Err = fmt.Errorf("Charge cannot be nil")
+			invalid++
+			continue
+		}
+		if c.ID != "" {
+			r.AlreadyCreated = true
+			continue
+		}
+		r.Err = c.validateNew()
+		if r.Err != nil {
+			invalid++
+			continue
+		}
+		c.Currency = eur
+		c.EmailOnSuccess = true
+		if opts.Key != nil {
+			r.IdempotencyKey = opts.Key(c)
+		} else {
+			r.IdempotencyKey = batchKey(opts.BatchID, c)
+		}
+		if j, ok := keys[r.IdempotencyKey]; ok {
+			r.Err = fmt.Errorf("Same idempotency key of charge %d: it would not be created", j)
+			invalid++
+			continue
+		}
+		keys[r.IdempotencyKey] = i
+	}
+	if invalid > 0 {
+		return results, fmt.Errorf("%d of %d charges are not valid, none has been sent", invalid, len(charges))
+	}
+
+	var b *bucket
+	if opts.Rate > 0 {
+		b = &bucket{cfg: RateLimit{Rate: opts.Rate, Burst: 1}, tokens: 1, last: time.Now()}
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := &results[i]
+				var err error
+				if b != nil {
+					err = b.wait(ctx)
+				}
+				if err == nil {
+					err = ctx.Err()
+				}
+				if err == nil {
+					err = p.WithContext(ContextWithIdempotencyKey(p.context(), r.IdempotencyKey)).Charges.create(r.Charge)
+				}
+				r.Err = err
+			}
+		}()
+	}
+	for i := range results {
+		if !results[i].AlreadyCreated {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return results, ctx.Err()
+	}
+	failed := len(results.Failed())
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d charges have not been created", failed, len(charges))
+	}
+	return results, nil
+}
@@ -0,0 +1,132 @@
+package satisgo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func batchCharges(t *testing.T, u satisgo.User, amounts ...float64) []*satisgo.Charge {
+	t.Helper()
+	list := make([]*satisgo.Charge, len(amounts))
+	for i, a := range amounts {
+		c, err := u.NewCharge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetAmmount(a)
+		c.SetCallbackURL("https://example.com/callback?charge_id={uuid}")
+		list[i] = c
+	}
+	return list
+}
+
+//lostResponse drops the response of the first creation of a charge of amount cents: the charge exists on the API
+func lostResponse(amount string) satisgo.Middleware {
+	var once sync.Once
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodPost || req.Body == nil {
+				return next.Do(req)
+			}
+			body, _ := io.ReadAll(req.Body)
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			resp, err := next.Do(req)
+			lost := false
+			if err == nil && strings.Contains(string(body), `"amount":`+amount+`,`) {
+				once.Do(func() { lost = true })
+			}
+			if lost {
+				resp.Body.Close()
+				return nil, errors.New("connection reset")
+			}
+			return resp, err
+		})
+	}
+}
+
+func TestCreateChargesRetry(t *testing.T) {
+	srv, p, u := newTestServer(t, satisgo.WithMiddleware(lostResponse("300")))
+	charges := batchCharges(t, u, 1, 3, 5)
+	results, err := p.CreateCharges(context.Background(), charges, satisgo.BatchOptions{BatchID: "event-1"})
+	if err == nil {
+		t.Fatal("the charge whose response is lost should be reported")
+	}
+	failed := results.Failed()
+	if len(failed) != 1 || failed[0] != charges[1] || charges[1].ID != "" {
+		t.Fatalf("got failed %+v, want the second charge", failed)
+	}
+	results, err = p.CreateCharges(context.Background(), failed, satisgo.BatchOptions{BatchID: "event-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Charge.ID == "" || results[0].AlreadyCreated {
+		t.Fatalf("unexpected result %+v", results[0])
+	}
+	//the whole list again: every charge is skipped
+	results, err = p.CreateCharges(context.Background(), charges, satisgo.BatchOptions{BatchID: "event-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.AlreadyCreated {
+			t.Fatalf("charge %d should be skipped", r.Index)
+		}
+	}
+	//the retry got the charge created by the lost call
+	if n := len(srv.Charges()); n != len(charges) {
+		t.Fatalf("got %d charges on the API, want %d", n, len(charges))
+	}
+}
+
+func TestCreateChargesDuplicates(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	charges := batchCharges(t, u, 2, 2)
+	results, err := p.CreateCharges(context.Background(), charges, satisgo.BatchOptions{BatchID: "event-1"})
+	if err == nil || results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf("the second equal charge should be refused: %v %+v", err, results)
+	}
+	if n := len(srv.Charges()); n != 0 {
+		t.Fatalf("got %d charges on the API, none should be sent", n)
+	}
+	//a Key telling the charges apart creates both
+	keys := map[*satisgo.Charge]string{charges[0]: "seat-1", charges[1]: "seat-2"}
+	_, err = p.CreateCharges(context.Background(), charges, satisgo.BatchOptions{
+		Key: func(c *satisgo.Charge) string { return keys[c] },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Charges()); n != 2 {
+		t.Fatalf("got %d charges on the API, want 2", n)
+	}
+}
+
+func TestCreateChargesInvalid(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	charges := batchCharges(t, u, 1, 0, 3)
+	charges[2].CallbackURL = ""
+	charges = append(charges, nil)
+	results, err := p.CreateCharges(context.Background(), charges, satisgo.BatchOptions{BatchID: "event-1"})
+	if err == nil {
+		t.Fatal("the batch should be refused")
+	}
+	for i, r := range results {
+		if (r.Err == nil) != (i == 0) {
+			t.Errorf("charge %d: got error %v", i, r.Err)
+		}
+	}
+	if n := len(srv.Charges()); n != 0 {
+		t.Fatalf("got %d charges on the API, none should be sent", n)
+	}
+	if _, err := p.CreateCharges(context.Background(), charges[:1], satisgo.BatchOptions{}); err == nil {
+		t.Fatal("a batch without BatchID should be refused")
+	}
+}
@@ -0,0 +1,237 @@
+package satisgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//ErrCircuitOpen is returned without calling the API while the circuit of the endpoint is open
+//Use errors.Is to check for it, errors.As with *CircuitOpenError gives the details
+var ErrCircuitOpen = errors.New("circuit open")
+
+//CircuitOpenError is the error returned while the circuit of an endpoint is open
+type CircuitOpenError struct {
+	Endpoint string
+	//RetryAt is when the circuit lets a probe request through again
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s endpoint until %s", e.Endpoint, e.RetryAt.Format(time.RFC3339))
+}
+
+//Is makes errors.Is(err, ErrCircuitOpen) true
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+//BreakerState is the state of the circuit of an endpoint
+type BreakerState int
+
+const (
+	//BreakerClosed lets every request through
+	BreakerClosed BreakerState = iota
+	//BreakerOpen fails every request fast
+	BreakerOpen
+	//BreakerHalfOpen lets a few probe requests through to find out if the API has recovered
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+//BreakerEvent is a change of state of the circuit of an endpoint
+type BreakerEvent struct {
+	Endpoint string
+	From     BreakerState
+	To       BreakerState
+	At       time.Time
+}
+
+//BreakerConfig configures the circuit breaker, zero values take the defaults
+type BreakerConfig struct {
+	//FailureThreshold is the number of consecutive failures opening the circuit (5)
+	FailureThreshold int
+	//OpenTimeout is how long the circuit stays open before letting probes through (30 seconds)
+	OpenTimeout time.Duration
+	//HalfOpenRequests is the number of successful probes closing the circuit again (1)
+	HalfOpenRequests int
+	//OnStateChange is called when a circuit trips or recovers, it must not block
+	OnStateChange func(BreakerEvent)
+}
+
+type circuit struct {
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+//breaker keeps a circuit for each endpoint of the API
+type breaker struct {
+	cfg      BreakerConfig
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+//WithCircuitBreaker makes the calls fail fast with ErrCircuitOpen when an endpoint keeps failing
+//Network errors and 5xx responses are failures, the other responses are successes
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(p *Satis) error {
+		if cfg.FailureThreshold < 0 || cfg.OpenTimeout < 0 || cfg.HalfOpenRequests < 0 {
+			return fmt.Errorf("circuit breaker thresholds cannot be negative")
+		}
+		if cfg.FailureThreshold == 0 {
+			cfg.FailureThreshold = 5
+		}
+		if cfg.OpenTimeout == 0 {
+			cfg.OpenTimeout = 30 * time.Second
+		}
+		if cfg.HalfOpenRequests == 0 {
+			cfg.HalfOpenRequests = 1
+		}
+		p.breaker = &breaker{cfg: cfg, circuits: make(map[string]*circuit)}
+		return nil
+	}
+}
+
+//BreakerState returns the state of the circuit of the endpoint (ex. EndpointCharges)
+//It is always BreakerClosed when no circuit breaker is configured
+func (p *Satis) BreakerState(endpoint string) BreakerState {
+	if p.breaker == nil {
+		return BreakerClosed
+	}
+	p.breaker.mu.Lock()
+	defer p.breaker.mu.Unlock()
+	c, ok := p.breaker.circuits[endpoint]
+	if !ok {
+		return BreakerClosed
+	}
+	if c.state == BreakerOpen && time.Since(c.openedAt) >= p.breaker.cfg.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return c.state
+}
+
+func (b *breaker) circuit(endpoint string) *circuit {
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		c = new(circuit)
+		b.circuits[endpoint] = c
+	}
+	return c
+}
+
+//set changes the state of the circuit, the event is returned to be emitted out of the lock
+func (b *breaker) set(endpoint string, c *circuit, to BreakerState) *BreakerEvent {
+	if c.state == to {
+		return nil
+	}
+	e := &BreakerEvent{Endpoint: endpoint, From: c.state, To: to, At: time.Now()}
+	c.state = to
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+	if to == BreakerOpen {
+		c.openedAt = e.At
+	}
+	return e
+}
+
+func (b *breaker) emit(e *BreakerEvent) {
+	if e != nil && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(*e)
+	}
+}
+
+//allow tells if a request to the endpoint can be sent
+func (b *breaker) allow(endpoint string) error {
+	b.mu.Lock()
+	c := b.circuit(endpoint)
+	var e *BreakerEvent
+	if c.state == BreakerOpen && time.Since(c.openedAt) >= b.cfg.OpenTimeout {
+		e = b.set(endpoint, c, BreakerHalfOpen)
+	}
+	var err error
+	switch c.state {
+	case BreakerOpen:
+		err = &CircuitOpenError{Endpoint: endpoint, RetryAt: c.openedAt.Add(b.cfg.OpenTimeout)}
+	case BreakerHalfOpen:
+		if c.probes >= b.cfg.HalfOpenRequests {
+			err = &CircuitOpenError{Endpoint: endpoint, RetryAt: time.Now().Add(b.cfg.OpenTimeout)}
+		} else {
+			c.probes++
+		}
+	}
+	b.mu.Unlock()
+	b.emit(e)
+	return err
+}
+
+//record counts the outcome of a request allowed by allow
+func (b *breaker) record(endpoint string, failed, ignore bool) {
+	b.mu.Lock()
+	c := b.circuit(endpoint)
+	var e *BreakerEvent
+	switch c.state {
+	case BreakerClosed:
+		if ignore {
+			break
+		}
+		if !failed {
+			c.failures = 0
+			break
+		}
+		c.failures++
+		if c.failures >= b.cfg.FailureThreshold {
+			e = b.set(endpoint, c, BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		if c.probes > 0 {
+			c.probes--
+		}
+		if ignore {
+			break
+		}
+		if failed {
+			e = b.set(endpoint, c, BreakerOpen)
+			break
+		}
+		c.successes++
+		if c.successes >= b.cfg.HalfOpenRequests {
+			e = b.set(endpoint, c, BreakerClosed)
+		}
+	}
+	b.mu.Unlock()
+	b.emit(e)
+}
+
+//wrap puts the breaker in front of d, base is the path of the base URL of the client
+func (b *breaker) wrap(d Doer, base string) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		endpoint := endpointOf(base, req.URL.Path)
+		err := b.allow(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := d.Do(req)
+		//a request canceled by the caller says nothing about the API
+		ignore := err != nil && req.Context().Err() != nil
+		failed := err != nil || resp.StatusCode >= 500
+		b.record(endpoint, failed, ignore)
+		return resp, err
+	})
+}
@@ -0,0 +1,119 @@
+package satisgo_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//outage answers 503 without calling the API while down is set, sent counts the calls reaching it
+func outage(down *atomic.Bool, sent *atomic.Int32) satisgo.Middleware {
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			sent.Add(1)
+			if !down.Load() {
+				return next.Do(req)
+			}
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader("")),
+				Request:    req,
+			}, nil
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var down atomic.Bool
+	var sent atomic.Int32
+	var mu sync.Mutex
+	var events []string
+	_, p, _ := newTestServer(t,
+		satisgo.WithMiddleware(outage(&down, &sent)),
+		satisgo.WithCircuitBreaker(satisgo.BreakerConfig{
+			FailureThreshold: 2,
+			OpenTimeout:      50 * time.Millisecond,
+			OnStateChange: func(e satisgo.BreakerEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, e.Endpoint+":"+e.From.String()+">"+e.To.String())
+			},
+		}),
+	)
+
+	//client errors are not failures
+	for i := 0; i < 3; i++ {
+		p.GetCharge("not-on-satispay")
+	}
+	if s := p.BreakerState(satisgo.EndpointCharges); s != satisgo.BreakerClosed {
+		t.Fatalf("404 responses opened the circuit: %s", s)
+	}
+
+	down.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := p.UserFromPhone(testPhone); err == nil || errors.Is(err, satisgo.ErrCircuitOpen) {
+			t.Fatalf("call %d: got %v, want the error of the API", i, err)
+		}
+	}
+	if s := p.BreakerState(satisgo.EndpointUsers); s != satisgo.BreakerOpen {
+		t.Fatalf("got state %s, want open", s)
+	}
+	before := sent.Load()
+	_, err := p.UserFromPhone(testPhone)
+	var open *satisgo.CircuitOpenError
+	if !errors.Is(err, satisgo.ErrCircuitOpen) || !errors.As(err, &open) || open.Endpoint != satisgo.EndpointUsers {
+		t.Fatalf("got %v, want the circuit of users open", err)
+	}
+	if sent.Load() != before {
+		t.Fatal("the API should not be called while the circuit is open")
+	}
+	if s := p.BreakerState(satisgo.EndpointCharges); s != satisgo.BreakerClosed {
+		t.Fatalf("the circuit of charges should not be affected, got %s", s)
+	}
+
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if s := p.BreakerState(satisgo.EndpointUsers); s != satisgo.BreakerHalfOpen {
+		t.Fatalf("got state %s after the timeout, want half-open", s)
+	}
+	_, err = p.UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.BreakerState(satisgo.EndpointUsers); s != satisgo.BreakerClosed {
+		t.Fatalf("got state %s after a successful probe, want closed", s)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := "users:closed>open users:open>half-open users:half-open>closed"
+	if got := strings.Join(events, " "); got != want {
+		t.Fatalf("got events %q, want %q", got, want)
+	}
+}
+
+func TestCircuitBreakerProbeFails(t *testing.T) {
+	var down atomic.Bool
+	var sent atomic.Int32
+	_, p, _ := newTestServer(t,
+		satisgo.WithMiddleware(outage(&down, &sent)),
+		satisgo.WithCircuitBreaker(satisgo.BreakerConfig{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}),
+	)
+	down.Store(true)
+	p.UserFromPhone(testPhone)
+	time.Sleep(30 * time.Millisecond)
+	p.UserFromPhone(testPhone)
+	if s := p.BreakerState(satisgo.EndpointUsers); s != satisgo.BreakerOpen {
+		t.Fatalf("a failed probe should open the circuit again, got %s", s)
+	}
+	if n := sent.Load(); n != 2 {
+		t.Fatalf("got %d calls, want the first one and the probe", n)
+	}
+}
@@ -0,0 +1,237 @@
+package satisgo
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Results of a lookup in the user cache, given to a CacheObserver
+const (
+	CacheHit         = "hit"
+	CacheNegativeHit = "negative_hit"
+	CacheMiss        = "miss"
+)
+
+//UserCacheEntry is a lookup kept by a UserCacheBackend, User is nil when the user does not exist
+type UserCacheEntry struct {
+	User    *User
+	Expires time.Time
+}
+
+//UserCacheBackend stores the lookups of UserFromPhone and UserFromID, it must be safe for concurrent use
+//The keys are "phone:" followed by the E.164 number or "id:" followed by the user id
+type UserCacheBackend interface {
+	Get(key string) (UserCacheEntry, bool)
+	Set(key string, e UserCacheEntry)
+	Delete(key string)
+}
+
+//UserCacheConfig configures the user cache, zero values take the defaults
+type UserCacheConfig struct {
+	//Backend stores the entries, NewLRUCache(1000) by default
+	Backend UserCacheBackend
+	//TTL is how long a user is kept (10 minutes)
+	TTL time.Duration
+	//NegativeTTL is how long a "user not found" answer is kept (1 minute), a negative value does not keep them
+	NegativeTTL time.Duration
+}
+
+//UserCacheStats counts the lookups in the user cache
+type UserCacheStats struct {
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+}
+
+//CacheObserver is an Observer that is also notified of every lookup in the user cache
+type CacheObserver interface {
+	Observer
+	//ObserveUserCache is called with CacheHit, CacheNegativeHit or CacheMiss
+	ObserveUserCache(result string)
+}
+
+type userCache struct {
+	cfg                   UserCacheConfig
+	hits, negHits, misses uint64
+}
+
+//WithUserCache caches the users found by UserFromPhone and UserFromID, and briefly the users not found
+func WithUserCache(cfg UserCacheConfig) Option {
+	return func(p *Satis) error {
+		if cfg.TTL < 0 {
+			return fmt.Errorf("TTL cannot be negative")
+		}
+		if cfg.TTL == 0 {
+			cfg.TTL = 10 * time.Minute
+		}
+		if cfg.NegativeTTL == 0 {
+			cfg.NegativeTTL = time.Minute
+		}
+		if cfg.Backend == nil {
+			cfg.Backend = NewLRUCache(1000)
+		}
+		p.users = &userCache{cfg: cfg}
+		return nil
+	}
+}
+
+//UserCacheStats returns the counters of the user cache, zero when there is no cache
+func (p *Satis) UserCacheStats() UserCacheStats {
+	if p.users == nil {
+		return UserCacheStats{}
+	}
+	return UserCacheStats{
+		Hits:         atomic.LoadUint64(&p.users.hits),
+		NegativeHits: atomic.LoadUint64(&p.users.negHits),
+		Misses:       atomic.LoadUint64(&p.users.misses),
+	}
+}
+
+//InvalidateUser removes the user from the cache (ex. when the customer tells the phone number has changed)
+func (p *Satis) InvalidateUser(u User) {
+	if u.ID != "" {
+		p.InvalidateUserID(u.ID)
+	}
+	if u.Phone != "" {
+		p.InvalidatePhone(u.Phone)
+	}
+}
+
+//InvalidatePhone removes the lookup of the phone number from the cache, found or not
+func (p *Satis) InvalidatePhone(phone string) {
+	if p.users == nil {
+		return
+	}
+	phone, err := NormalizePhone(phone, p.region)
+	if err != nil {
+		return
+	}
+	p.users.cfg.Backend.Delete("phone:" + phone)
+}
+
+//InvalidateUserID removes the lookup of the user id from the cache, found or not
+func (p *Satis) InvalidateUserID(id string) {
+	if p.users != nil {
+		p.users.cfg.Backend.Delete("id:" + id)
+	}
+}
+
+//cachedUser looks the key up, ok is false when the API must be called
+func (p *Satis) cachedUser(key string, sp span) (_ *User, ok bool, _ error) {
+	if p.users == nil {
+		return nil, false, nil
+	}
+	result := CacheMiss
+	defer func() {
+		sp.set(attrString(AttrCache, result))
+		if o, ok := p.observer.(CacheObserver); ok {
+			o.ObserveUserCache(result)
+		}
+	}()
+	e, found := p.users.cfg.Backend.Get(key)
+	if found && time.Now().After(e.Expires) {
+		p.users.cfg.Backend.Delete(key)
+		found = false
+	}
+	if !found {
+		atomic.AddUint64(&p.users.misses, 1)
+		return nil, false, nil
+	}
+	if e.User == nil {
+		result = CacheNegativeHit
+		atomic.AddUint64(&p.users.negHits, 1)
+		return nil, true, &APIError{StatusCode: 404}
+	}
+	result = CacheHit
+	atomic.AddUint64(&p.users.hits, 1)
+	u := *e.User
+	return &u, true, nil
+}
+
+//cacheUser keeps the result of a lookup: the users found and the ones not found, not the other errors
+func (p *Satis) cacheUser(key string, u *User, err error) {
+	if p.users == nil {
+		return
+	}
+	cfg := p.users.cfg
+	switch {
+	case err == nil:
+		e := UserCacheEntry{User: &User{ID: u.ID, Phone: u.Phone}, Expires: time.Now().Add(cfg.TTL)}
+		cfg.Backend.Set("id:"+u.ID, e)
+		if phone, err := NormalizePhone(u.Phone, p.region); err == nil {
+			cfg.Backend.Set("phone:"+phone, e)
+		}
+	case IsNotFound(err) && cfg.NegativeTTL > 0:
+		cfg.Backend.Set(key, UserCacheEntry{Expires: time.Now().Add(cfg.NegativeTTL)})
+	}
+}
+
+//LRUCache is an in memory UserCacheBackend dropping the least recently used entries when full
+type LRUCache struct {
+	size  int
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry UserCacheEntry
+}
+
+//NewLRUCache returns an LRUCache keeping at most size entries
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+//Get implements UserCacheBackend
+func (c *LRUCache) Get(key string) (UserCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return UserCacheEntry{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruItem).entry, true
+}
+
+//Set implements UserCacheBackend
+func (c *LRUCache) Set(key string, e UserCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruItem).entry = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruItem{key: key, entry: e})
+	if c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*lruItem).key)
+	}
+}
+
+//Delete implements UserCacheBackend
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+//Len is the number of entries in the cache
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,108 @@
+package satisgo_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//counter is a middleware counting the calls that reach the API
+func counter(n *atomic.Int32) satisgo.Middleware {
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			n.Add(1)
+			return next.Do(req)
+		})
+	}
+}
+
+func TestUserCache(t *testing.T) {
+	var calls atomic.Int32
+	_, p, u := newTestServer(t,
+		satisgo.WithMiddleware(counter(&calls)),
+		satisgo.WithUserCache(satisgo.UserCacheConfig{}),
+	)
+	for i := 0; i < 3; i++ {
+		got, err := p.UserFromPhone("333 123 4567")
+		if err != nil || got.ID != u.ID {
+			t.Fatalf("got %+v, %v", got, err)
+		}
+	}
+	//the lookup by phone fills the lookup by id too
+	got, err := p.UserFromID(u.ID)
+	if err != nil || got.Phone != testPhone {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = p.UserFromPhone("+393339999999")
+		if !satisgo.IsNotFound(err) {
+			t.Fatalf("got %v, want a not found error", err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("got %d calls, want one for each phone number", n)
+	}
+	want := satisgo.UserCacheStats{Hits: 3, NegativeHits: 1, Misses: 2}
+	if s := p.UserCacheStats(); s != want {
+		t.Fatalf("got stats %+v, want %+v", s, want)
+	}
+
+	p.InvalidatePhone("3331234567")
+	p.InvalidatePhone("+393339999999")
+	p.UserFromPhone(testPhone)
+	p.UserFromPhone("+393339999999")
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("got %d calls, the invalidated numbers should be looked up again", n)
+	}
+	p.InvalidateUser(u)
+	p.UserFromID(u.ID)
+	if n := calls.Load(); n != 5 {
+		t.Fatalf("got %d calls, the invalidated user should be looked up again", n)
+	}
+}
+
+func TestUserCacheTTL(t *testing.T) {
+	var calls atomic.Int32
+	_, p, _ := newTestServer(t,
+		satisgo.WithMiddleware(counter(&calls)),
+		satisgo.WithUserCache(satisgo.UserCacheConfig{TTL: 20 * time.Millisecond, NegativeTTL: -1}),
+	)
+	p.UserFromPhone(testPhone)
+	p.UserFromPhone(testPhone)
+	time.Sleep(30 * time.Millisecond)
+	p.UserFromPhone(testPhone)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("got %d calls, want a new one after the TTL", n)
+	}
+	//negative answers are not kept
+	p.UserFromPhone("+393339999999")
+	p.UserFromPhone("+393339999999")
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("got %d calls, users not found should not be cached", n)
+	}
+	if _, err := satisgo.New("token", "staging", satisgo.WithUserCache(satisgo.UserCacheConfig{TTL: -1})); err == nil {
+		t.Fatal("a negative TTL should be refused")
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	c := satisgo.NewLRUCache(2)
+	e := satisgo.UserCacheEntry{Expires: time.Now().Add(time.Hour)}
+	c.Set("a", e)
+	c.Set("b", e)
+	c.Get("a")
+	c.Set("c", e)
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("the least recently used entry should be dropped")
+	}
+	if _, ok := c.Get("a"); !ok || c.Len() != 2 {
+		t.Fatalf("got %d entries, want a and c", c.Len())
+	}
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Fatal("the entry should be deleted")
+	}
+}
@@ -46,6 +46,8 @@ type Charge struct {
 }
 
 //NewCharge generates a charge based on the obtained user
+//
+//Deprecated: use p.Charges.Create
 func (u *User) NewCharge() (*Charge, error) {
 	if u.ID == "" {
 		return nil, fmt.Errorf("not possible to create a charge if user_id is empty")
@@ -58,14 +60,23 @@ func (u *User) NewCharge() (*Charge, error) {
 }
 
 //GetCharge returns a charge provided a charge_id
+//
+//Deprecated: use p.Charges.Get
 func (p *Satis) GetCharge(id string) (*Charge, error) {
+	return p.Charges.Get(id)
+}
+
+//Get returns the charge with the given id
+func (s *ChargeService) Get(id string) (_ *Charge, err error) {
+	p, span := s.p.startSpan("GetCharge", attrString(AttrChargeID, id))
+	defer span.end(&err)
 	r, err := http.NewRequest("GET", p.chargesURL()+"/"+id, nil)
 	if err != nil {
 		return nil, err
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return nil, fmt.Errorf("Error making the call to API: %s", err.Error())
+		return nil, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return nil, fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -75,11 +86,29 @@ func (p *Satis) GetCharge(id string) (*Charge, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
+	//a response replayed from another call would still be signed
+	if c.ID != id {
+		return nil, fmt.Errorf("Response is about charge %q instead of %q", c.ID, id)
+	}
+	span.set(chargeAttrs(c)...)
 	return c, nil
 }
 
 //CancelCharge cancel a charge not yet approved by client
+//The status of c must be REQUIRED, use Refresh if c is not up to date
+//
+//Deprecated: use p.Charges.Cancel
 func (c *Charge) CancelCharge(p *Satis) error {
+	return p.Charges.cancel(c)
+}
+
+//cancel cancels c, which must be the charge as it is on Satispay
+func (s *ChargeService) cancel(c *Charge) (err error) {
+	p, span := s.p.startSpan("CancelCharge", attrString(AttrChargeID, c.ID))
+	defer span.end(&err)
+	if !c.CanCancel() {
+		return &StateError{ChargeID: c.ID, Status: c.Status, Op: "cancel"}
+	}
 	input := strings.NewReader(`{"charge_state":"CANCELED"}`)
 	r, err := http.NewRequest("PUT", p.chargesURL()+"/"+c.ID, input)
 	if err != nil {
@@ -87,7 +116,7 @@ func (c *Charge) CancelCharge(p *Satis) error {
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return fmt.Errorf("Error making the call to API: %s", err.Error())
+		return fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -97,53 +126,54 @@ func (c *Charge) CancelCharge(p *Satis) error {
 	if err != nil {
 		return fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
+	p.transition(c, ch)
 	*c = *ch
+	span.set(chargeAttrs(c)...)
 	return nil
 }
 
 //UpdateChargeDescription returns a modified Charge provided one
+//
+//Deprecated: use p.Charges.Update
 func (c *Charge) UpdateChargeDescription(p *Satis) error {
-	type body struct {
-		Description string `json:"description"`
-	}
-	var bod body
-	bod.Description = c.Description
-	data, err := json.Marshal(&bod)
+	ch, err := p.Charges.Update(c.ID, ChargeUpdateParams{Description: &c.Description})
 	if err != nil {
 		return err
 	}
-	input := bytes.NewReader(data)
-	r, err := http.NewRequest("PUT", p.chargesURL()+"/"+c.ID, input)
-	if err != nil {
-		return err
-	}
-	status, b, err := p.makeCall(r)
-	if err != nil {
-		return fmt.Errorf("Error making the call to API: %s", err.Error())
-	}
-	if status != 200 {
-		return fmt.Errorf("Return status is %d:not compatible with the success case", status)
-	}
-	ch := new(Charge)
-	err = json.Unmarshal(b, ch)
-	if err != nil {
-		return fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
-	}
 	*c = *ch
 	return nil
 }
 
 //UpdateChargeMetadata returns a modified Charge provided one
+//
+//Deprecated: use p.Charges.Update
 func (c *Charge) UpdateChargeMetadata(p *Satis) error {
 	if c.Metadata == nil {
 		return fmt.Errorf("metadata not initialized yet, nothing to update")
 	}
-	type body struct {
-		Metadata map[string]string `json:"metadata"`
+	ch, err := p.Charges.Update(c.ID, ChargeUpdateParams{Metadata: c.Metadata})
+	if err != nil {
+		return err
 	}
-	var bod body
-	bod.Metadata = c.Metadata
-	data, err := json.Marshal(&bod)
+	*c = *ch
+	return nil
+}
+
+//update sends the description and the metadata of params in a single PUT, c must be the charge as it is on Satispay
+func (s *ChargeService) update(c *Charge, params ChargeUpdateParams) (err error) {
+	p, span := s.p.startSpan("UpdateCharge", attrString(AttrChargeID, c.ID))
+	defer span.end(&err)
+	bod := make(map[string]interface{})
+	if params.Description != nil {
+		if !c.CanUpdateDescription() {
+			return &StateError{ChargeID: c.ID, Status: c.Status, Op: "update description"}
+		}
+		bod["description"] = *params.Description
+	}
+	if params.Metadata != nil {
+		bod["metadata"] = params.Metadata
+	}
+	data, err := json.Marshal(bod)
 	if err != nil {
 		return err
 	}
@@ -154,7 +184,7 @@ func (c *Charge) UpdateChargeMetadata(p *Satis) error {
 	}
 	status, b, err := p.makeCall(req)
 	if err != nil {
-		return fmt.Errorf("Error making the call to API: %s", err.Error())
+		return fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -164,7 +194,10 @@ func (c *Charge) UpdateChargeMetadata(p *Satis) error {
 	if err != nil {
 		return fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
+	p.indexCharge(c.Metadata, ch)
+	p.transition(c, ch)
 	*c = *ch
+	span.set(chargeAttrs(c)...)
 	return nil
 }
 
@@ -213,44 +246,44 @@ func (c *Charge) SetCallbackURL(s string) error {
 
 //SetMetadata is used to add metadata to a charge without making any mess around
 //THere are some limits: max 20pairs, key length max 45 chars, value max 500 chars
+//An empty value deletes the key
 //THIS IS NOT MANDATORY
 func (c *Charge) SetMetadata(key, value string) error {
-	if len(key) > 45 || len(key) < 2 {
-		return fmt.Errorf("The key has the wrong format (too long/short)")
-	}
-	if len(value) > 500 {
-		return fmt.Errorf("The value has the wrong format (too long/short)")
-	}
-	if c.Metadata == nil {
-		c.Metadata = make(map[string]string)
-	}
-	_, exist := c.Metadata[key]
-	if exist && value == "" {
-		delete(c.Metadata, key)
-		return nil
-	}
-	if len(c.Metadata) == 20 && !exist {
-		return fmt.Errorf("Metadata is too long already")
+	m, err := setMetadata(c.Metadata, key, value)
+	c.Metadata = m
+	return err
+}
+
+//SetMetadataStruct merges the fields of v tagged with `metadata:"key[,omitempty]"` into the metadata
+//ints, floats, bools, strings and encoding.TextMarshaler (time.Time, enums) are supported.
+//Nothing is changed if a limit would be exceeded
+func (c *Charge) SetMetadataStruct(v interface{}) error {
+	m, err := encodeMetadata(c.Metadata, v)
+	if err != nil {
+		return err
 	}
-	c.Metadata[key] = value
+	c.Metadata = m
 	return nil
 }
 
-//CreateCharge is the function that makes the call to Satispay API
-func (c *Charge) CreateCharge(p *Satis) error {
+//DecodeMetadata fills the fields of v tagged with `metadata:"key"` from the metadata of the charge
+func (c *Charge) DecodeMetadata(v interface{}) error {
+	return decodeMetadata(c.Metadata, v)
+}
+
+//validateNew checks that c can be sent to create a charge
+func (c *Charge) validateNew() error {
 	if c.UserID == "" {
 		return fmt.Errorf("User_ID cannot be empty")
 	}
 	if c.Amount == 0 {
 		return fmt.Errorf("Amount cannot be empty")
 	}
-	c.Currency = eur
-	c.EmailOnSuccess = true
 	if c.ID != "" {
 		return fmt.Errorf("Charge ID already exist: charge already created")
 	}
-	if len(c.Metadata) > 20 {
-		return fmt.Errorf("Metadata is too long")
+	if err := validateMetadata(c.Metadata); err != nil {
+		return err
 	}
 	if c.ExpireDate != "" {
 		return fmt.Errorf("Charge expire_date already exist: charge already created")
@@ -258,6 +291,26 @@ func (c *Charge) CreateCharge(p *Satis) error {
 	if c.CallbackURL == "" {
 		return fmt.Errorf("CallbackURL cannot be empty")
 	}
+	return nil
+}
+
+//CreateCharge is the function that makes the call to Satispay API
+//
+//Deprecated: use p.Charges.Create
+func (c *Charge) CreateCharge(p *Satis) error {
+	return p.Charges.create(c)
+}
+
+//create sends c to Satispay and fills it with the charge created
+func (s *ChargeService) create(c *Charge) (err error) {
+	p, span := s.p.startSpan("CreateCharge", attrInt(AttrAmount, int64(c.Amount)))
+	defer span.end(&err)
+	err = c.validateNew()
+	if err != nil {
+		return err
+	}
+	c.Currency = eur
+	c.EmailOnSuccess = true
 	//some more checking if Charge object is good
 	data, err := json.Marshal(c)
 	if err != nil {
@@ -271,7 +324,7 @@ func (c *Charge) CreateCharge(p *Satis) error {
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return fmt.Errorf("Error making the call to API: %s", err.Error())
+		return fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -282,5 +335,8 @@ func (c *Charge) CreateCharge(p *Satis) error {
 		return fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
 	*c = *charg
+	p.indexCharge(nil, c)
+	p.transition(new(Charge), c)
+	span.set(chargeAttrs(c)...)
 	return nil
 }
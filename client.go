@@ -0,0 +1,90 @@
+package satisgo
+
+import (
+	"context"
+	"time"
+)
+
+//Client is the set of operations on the API, grouped as in p.Users, p.Charges, p.Refunds and p.Amounts.
+//*Satis implements it: depend on Client in your code to substitute a fake in the tests
+//(see satisgotest.Mock, or satisgotest.Server to keep a real *Satis)
+type Client interface {
+	//BindContext returns a Client whose calls are bound to ctx, it is WithContext for the code depending on Client
+	BindContext(ctx context.Context) Client
+
+	Verify() error
+
+	UserAPI() UserAPI
+	ChargeAPI() ChargeAPI
+	RefundAPI() RefundAPI
+	AmountAPI() AmountAPI
+}
+
+//UserAPI is the interface of UserService
+type UserAPI interface {
+	Get(id string) (*User, error)
+	Lookup(phone string) (*User, error)
+	List() ([]User, error)
+}
+
+//ChargeAPI is the interface of ChargeService
+type ChargeAPI interface {
+	Get(id string) (*Charge, error)
+	Create(params ChargeCreateParams) (*Charge, error)
+	Cancel(id string) (*Charge, error)
+	Update(id string, params ChargeUpdateParams) (*Charge, error)
+	List(params *ChargeListParams) ([]Charge, error)
+}
+
+//RefundAPI is the interface of RefundService
+type RefundAPI interface {
+	Get(id string) (*Refund, error)
+	Create(params RefundCreateParams) (*Refund, error)
+	Update(id string, params RefundUpdateParams) (*Refund, error)
+	List(params *RefundListParams) ([]Refund, error)
+}
+
+//AmountAPI is the interface of AmountService
+type AmountAPI interface {
+	Today() (*Ammount, error)
+	Yesterday() (*Ammount, error)
+	Date(year, month, day int) (*Ammount, error)
+	ThisWeek() (*Ammount, error)
+	ThisMonth() (*Ammount, error)
+	ThisYear() (*Ammount, error)
+	Year(year int) (*Ammount, error)
+	Range(from, to time.Time) (*Ammount, error)
+}
+
+var (
+	_ Client    = (*Satis)(nil)
+	_ UserAPI   = (*UserService)(nil)
+	_ ChargeAPI = (*ChargeService)(nil)
+	_ RefundAPI = (*RefundService)(nil)
+	_ AmountAPI = (*AmountService)(nil)
+)
+
+//BindContext is p.WithContext(ctx) as a Client
+func (p *Satis) BindContext(ctx context.Context) Client {
+	return p.WithContext(ctx)
+}
+
+//UserAPI returns p.Users
+func (p *Satis) UserAPI() UserAPI {
+	return p.Users
+}
+
+//ChargeAPI returns p.Charges
+func (p *Satis) ChargeAPI() ChargeAPI {
+	return p.Charges
+}
+
+//RefundAPI returns p.Refunds
+func (p *Satis) RefundAPI() RefundAPI {
+	return p.Refunds
+}
+
+//AmountAPI returns p.Amounts
+func (p *Satis) AmountAPI() AmountAPI {
+	return p.Amounts
+}
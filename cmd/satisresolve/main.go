@@ -0,0 +1,186 @@
+/*
+Satisresolve reads a CSV of phone numbers and writes which ones belong to a Satispay user.
+
+	SATISPAY_TOKEN=... satisresolve -in phones.csv -column phone -out users.csv
+
+The output has the columns phone, normalized, status (found, not_found, invalid, error), user_id and error.
+The exit status is 1 when the run stops before the end or some lookups end with an error, 2 for a wrong usage.
+*/
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Getenv("SATISPAY_TOKEN"), os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+//run is the command with its arguments and standard files, it returns the exit status
+func run(ctx context.Context, args []string, token string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("satisresolve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	in := flags.String("in", "-", "CSV file with the phone numbers, - for stdin")
+	out := flags.String("out", "-", "CSV file for the results, - for stdout")
+	column := flags.String("column", "0", "name (when the file has a header) or index of the column with the phone numbers")
+	header := flags.Bool("header", true, "the first row of the input is a header")
+	env := flags.String("env", "production", "Satispay environment: production or staging")
+	region := flags.String("region", satisgo.DefaultRegion, "region of the numbers without the international prefix")
+	workers := flags.Int("workers", 8, "lookups running at the same time")
+	rate := flags.Float64("rate", 10, "lookups per second, 0 means no limit")
+	baseURL := flags.String("url", "", "base URL of the API, the Satispay one when empty")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+	logger := log.New(stderr, "satisresolve: ", 0)
+
+	if token == "" {
+		logger.Print("SATISPAY_TOKEN is not set")
+		return 2
+	}
+	opts := []satisgo.Option{satisgo.WithDefaultRegion(*region)}
+	if *baseURL != "" {
+		opts = append(opts, satisgo.WithBaseURL(*baseURL))
+	}
+	p, err := satisgo.New(token, *env, opts...)
+	if err != nil {
+		logger.Print(err)
+		return 2
+	}
+	r, err := open(*in, stdin)
+	if err != nil {
+		logger.Print(err)
+		return 1
+	}
+	defer r.Close()
+	phones, err := readPhones(csv.NewReader(r), *column, *header)
+	if err != nil {
+		logger.Print(err)
+		return 1
+	}
+	w, err := create(*out, stdout)
+	if err != nil {
+		logger.Print(err)
+		return 1
+	}
+
+	var done int64
+	results, err := p.ResolveUsers(ctx, phones, satisgo.ResolveOptions{
+		Workers: *workers,
+		Rate:    *rate,
+		OnResult: func(satisgo.ResolveResult) {
+			n := atomic.AddInt64(&done, 1)
+			if n%100 == 0 {
+				logger.Printf("%d/%d resolved", n, len(phones))
+			}
+		},
+	})
+	if err != nil {
+		logger.Printf("stopped: %s", err.Error())
+	}
+	werr := writeResults(csv.NewWriter(w), results)
+	cerr := w.Close()
+	if werr != nil || cerr != nil {
+		logger.Print("writing the results: ", werr, cerr)
+		return 1
+	}
+	if err != nil {
+		return 1
+	}
+	for _, r := range results {
+		if r.Status == satisgo.ResolveError {
+			logger.Print("some lookups failed, see the error column")
+			return 1
+		}
+	}
+	return 0
+}
+
+func open(name string, stdin io.Reader) (io.ReadCloser, error) {
+	if name == "-" {
+		return ioutil.NopCloser(stdin), nil
+	}
+	return os.Open(name)
+}
+
+//nopCloser does not close the standard output
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func create(name string, stdout io.Writer) (io.WriteCloser, error) {
+	if name == "-" {
+		return nopCloser{stdout}, nil
+	}
+	return os.Create(name)
+}
+
+//readPhones returns the values of the column, found by name in the header or by index
+func readPhones(r *csv.Reader, column string, header bool) ([]string, error) {
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	col, err := strconv.Atoi(column)
+	if header && len(rows) > 0 {
+		if err != nil {
+			col = -1
+			for i, name := range rows[0] {
+				if name == column {
+					col = i
+				}
+			}
+			if col < 0 {
+				return nil, fmt.Errorf("Column %s not found in the header", column)
+			}
+		}
+		rows = rows[1:]
+	} else if err != nil {
+		return nil, fmt.Errorf("Column must be an index when the file has no header")
+	}
+	if col < 0 {
+		return nil, fmt.Errorf("Column %d is not valid", col)
+	}
+	phones := make([]string, 0, len(rows))
+	for i, row := range rows {
+		if col >= len(row) {
+			return nil, fmt.Errorf("Row %d has no column %d", i+1, col)
+		}
+		phones = append(phones, row[col])
+	}
+	return phones, nil
+}
+
+func writeResults(w *csv.Writer, results []satisgo.ResolveResult) error {
+	w.Write([]string{"phone", "normalized", "status", "user_id", "error"})
+	for _, r := range results {
+		var id, msg string
+		if r.User != nil {
+			id = r.User.ID
+		}
+		if r.Err != nil {
+			msg = r.Err.Error()
+		}
+		w.Write([]string{r.Phone, r.Normalized, r.Status, id, msg})
+	}
+	w.Flush()
+	return w.Error()
+}
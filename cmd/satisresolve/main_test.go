@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+const broken = "+393339999999"
+
+//resolve runs the command on the CSV against the fake API, the lookups of broken fail with a 500
+func resolve(t *testing.T, input string, args ...string) (int, [][]string, string) {
+	t.Helper()
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddUser("+393331234567")
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), broken) {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(front.Close)
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "phones.csv")
+	out := filepath.Join(dir, "users.csv")
+	err := ioutil.WriteFile(in, []byte(input), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args = append([]string{"-env", "staging", "-url", front.URL, "-rate", "0", "-in", in, "-out", out}, args...)
+	var stderr bytes.Buffer
+	code := run(context.Background(), args, srv.Bearer, nil, nil, &stderr)
+	f, err := os.Open(out)
+	if err != nil {
+		return code, nil, stderr.String()
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, rows, stderr.String()
+}
+
+func TestResolve(t *testing.T) {
+	code, rows, stderr := resolve(t, "name,phone\nalice,+39 333 123 4567\nbob,3330000000\ncarl,not a phone\n", "-column", "phone")
+	if code != 0 {
+		t.Fatalf("got exit status %d, want 0: %s", code, stderr)
+	}
+	want := [][]string{
+		{"phone", "normalized", "status", "user_id", "error"},
+		{"+39 333 123 4567", "+393331234567", "found"},
+		{"3330000000", "+393330000000", "not_found"},
+		{"not a phone", "", "invalid"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(rows), len(want), rows)
+	}
+	for i, w := range want {
+		for j, v := range w {
+			if rows[i][j] != v {
+				t.Errorf("row %d column %d: got %q, want %q", i, j, rows[i][j], v)
+			}
+		}
+	}
+	if rows[1][3] == "" || rows[3][4] == "" {
+		t.Errorf("missing user id or error: %v", rows)
+	}
+}
+
+func TestResolvePartialFailure(t *testing.T) {
+	code, rows, _ := resolve(t, "3331234567\n"+broken+"\n", "-header=false")
+	if code != 1 {
+		t.Fatalf("got exit status %d, want 1", code)
+	}
+	if len(rows) != 3 || rows[1][2] != "found" || rows[2][2] != "error" || rows[2][4] == "" {
+		t.Fatalf("the results should be written anyway, got %v", rows)
+	}
+}
+
+func TestResolveCSV(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		args  []string
+		code  int
+	}{
+		{"column not in the header", "phone\n3331234567\n", []string{"-column", "mobile"}, 1},
+		{"column name without header", "3331234567\n", []string{"-header=false", "-column", "phone"}, 1},
+		{"short row", "a,b\n1,2\n3\n", []string{"-column", "1"}, 1},
+		{"wrong flag", "phone\n", []string{"-workers", "many"}, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, _, _ := resolve(t, tc.input, tc.args...)
+			if code != tc.code {
+				t.Fatalf("got exit status %d, want %d", code, tc.code)
+			}
+		})
+	}
+	if code := run(context.Background(), nil, "", nil, nil, ioutil.Discard); code != 2 {
+		t.Fatalf("without a token: got exit status %d, want 2", code)
+	}
+}
@@ -0,0 +1,135 @@
+package satisgo_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+//these tests are meant to be run with the race detector: go test -race
+
+func TestConcurrentCharges(t *testing.T) {
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	u := srv.AddUser("+393331234567")
+	events := make(chan satisgo.ChargeEvent, 1000)
+	p, err := srv.Client(
+		satisgo.WithChargeIndex(satisgo.NewMemoryIndex(), "order_id"),
+		satisgo.WithChargeEvents(func(e satisgo.ChargeEvent) { events <- e }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- chargeFlow(p.WithContext(context.Background()), u, i)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := len(srv.Charges()); n != workers {
+		t.Fatalf("got %d charges on the server, want %d", n, workers)
+	}
+	//one creation and one cancellation for each worker
+	if n := len(events); n != 2*workers {
+		t.Fatalf("got %d charge events, want %d", n, 2*workers)
+	}
+	if !p.Verified() {
+		t.Fatal("client should be verified")
+	}
+}
+
+func chargeFlow(p *satisgo.Satis, u satisgo.User, i int) error {
+	c, err := u.NewCharge()
+	if err != nil {
+		return err
+	}
+	c.SetAmmount(float64(i + 1))
+	c.SetCallbackURL("http://example.com/callback?charge_id={uuid}")
+	c.SetMetadata("order_id", fmt.Sprint(i))
+	err = c.CreateCharge(p)
+	if err != nil {
+		return err
+	}
+	got, err := p.GetCharge(c.ID)
+	if err != nil {
+		return err
+	}
+	if got.Amount != c.Amount {
+		return fmt.Errorf("charge %s: got amount %d, want %d", c.ID, got.Amount, c.Amount)
+	}
+	found, err := p.FindChargesByMetadata(context.Background(), "order_id", fmt.Sprint(i))
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != c.ID {
+		return fmt.Errorf("order %d: found %v", i, found)
+	}
+	_, err = p.GetAllCharges()
+	if err != nil {
+		return err
+	}
+	return c.CancelCharge(p)
+}
+
+func TestConcurrentReads(t *testing.T) {
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	u := srv.AddUser("+393331234567")
+	p, err := srv.Client(satisgo.WithRateLimit("", satisgo.RateLimit{MaxInFlight: 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			_, err := p.UserFromID(u.ID)
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := p.GetAllUsers()
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := p.GetAllRefunds()
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := p.AmmountToday()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package satisgo
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
 const (
 	prod     = "https://authservices.satispay.com"
 	sand     = "https://staging.authservices.satispay.com"
@@ -19,6 +25,9 @@ const (
 	Success = "SUCCESS"
 	//Failure represent payment Failure
 	Failure = "FAILURE"
+	//Canceled is the charge_state sent to cancel a charge and the StatusDetails of a canceled charge
+	//(its Status is FAILURE, like the other charges that will never be paid)
+	Canceled = "CANCELED"
 	//ErrDeclined represent a cancellation from the user
 	ErrDeclined = "DECLINED_BY_PAYER"
 	//ErrFalseRequest represent a cancellation by the wrong user
@@ -44,37 +53,52 @@ var (
 	debug = true
 )
 
-func (p *Satis) verificationURL() string {
-	if p.env == dev {
-		return sand + auth
+//WithBaseURL makes the client call another host instead of the Satispay one (ex. a fake server in tests)
+func WithBaseURL(u string) Option {
+	return func(p *Satis) error {
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return fmt.Errorf("base URL must be http or https: %s", u)
+		}
+		p.baseURL = strings.TrimSuffix(u, "/")
+		return nil
 	}
-	return prod + auth
 }
 
-func (p *Satis) usersURL() string {
+func (p *Satis) host() string {
+	if p.baseURL != "" {
+		return p.baseURL
+	}
 	if p.env == dev {
-		return sand + users
+		return sand
 	}
-	return prod + users
+	return prod
 }
 
-func (p *Satis) chargesURL() string {
-	if p.env == dev {
-		return sand + charges
+//basePath is the path of the host, the API paths follow it (ex. "/api" for WithBaseURL("http://localhost/api"))
+func (p *Satis) basePath() string {
+	u, err := url.Parse(p.host())
+	if err != nil {
+		return ""
 	}
-	return prod + charges
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+func (p *Satis) verificationURL() string {
+	return p.host() + auth
+}
+
+func (p *Satis) usersURL() string {
+	return p.host() + users
+}
+
+func (p *Satis) chargesURL() string {
+	return p.host() + charges
 }
 
 func (p *Satis) refundsURL() string {
-	if p.env == dev {
-		return sand + refunds
-	}
-	return prod + refunds
+	return p.host() + refunds
 }
 
 func (p *Satis) ammountsURL() string {
-	if p.env == dev {
-		return sand + ammounts
-	}
-	return prod + ammounts
+	return p.host() + ammounts
 }
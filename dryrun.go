@@ -0,0 +1,299 @@
+package satisgo
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//RecordedRequest is a mutating call kept by a DryRun instead of being sent
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	//Body is the JSON that would have been sent
+	Body           json.RawMessage `json:"body,omitempty"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	At             time.Time       `json:"at"`
+	//Response is the synthetic JSON given back to the client
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+//DryRun keeps the charges and refunds created by a client in dry run mode and the requests it did not send
+type DryRun struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+	charges  map[string]*Charge
+	refunds  map[string]*Refund
+}
+
+//NewDryRun returns an empty DryRun
+func NewDryRun() *DryRun {
+	return &DryRun{charges: make(map[string]*Charge), refunds: make(map[string]*Refund)}
+}
+
+//WithDryRun does not send the mutating calls (creation, cancellation and updates of charges and refunds):
+//they are validated and recorded in d, and answered with realistic synthetic responses.
+//The reads are sent to the API, the charges and refunds created by d are read from d,
+//any other call that is not known to be a read returns an error without being sent.
+//Nothing that moves money reaches Satispay, so a production client can be used to test a new flow
+func WithDryRun(d *DryRun) Option {
+	return func(p *Satis) error {
+		if d == nil {
+			return fmt.Errorf("DryRun cannot be nil")
+		}
+		p.dryRun = d
+		return nil
+	}
+}
+
+//Requests returns a copy of the requests recorded, in order
+func (d *DryRun) Requests() []RecordedRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]RecordedRequest(nil), d.requests...)
+}
+
+//Reset forgets the requests, charges and refunds recorded
+func (d *DryRun) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = nil
+	d.charges = make(map[string]*Charge)
+	d.refunds = make(map[string]*Refund)
+}
+
+//wrap answers the mutating calls in place of next, pol checks the real objects read to update them
+//base is the path of the base URL of the client, the API paths follow it.
+//The calls that are not known to be reads are never sent
+func (d *DryRun) wrap(next Doer, pol *IntegrityPolicy, base string) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		path := strings.TrimSuffix(req.URL.Path, "/")
+		if !strings.HasPrefix(path, base) {
+			return nil, fmt.Errorf("Dry run: %s %s is not a call to the API, it has not been sent", req.Method, req.URL.Path)
+		}
+		path = strings.TrimPrefix(path, base)
+		var kind, id string
+		switch {
+		case path == charges || path == refunds:
+			kind = path
+		case strings.HasPrefix(path, charges+"/"):
+			kind, id = charges, strings.TrimPrefix(path, charges+"/")
+		case strings.HasPrefix(path, refunds+"/"):
+			kind, id = refunds, strings.TrimPrefix(path, refunds+"/")
+		case req.Method == http.MethodGet || (req.Method == http.MethodPost && path == users):
+			//the lookup of a user by phone number is a POST that changes nothing
+			return next.Do(req)
+		default:
+			return nil, fmt.Errorf("Dry run: %s %s is not a known call, it has not been sent", req.Method, req.URL.Path)
+		}
+		if req.Method == http.MethodGet {
+			if v, ok := d.lookup(kind, id); ok {
+				return d.synthesize(req, http.StatusOK, v), nil
+			}
+			return next.Do(req)
+		}
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		rec := RecordedRequest{
+			Method:         req.Method,
+			Path:           req.URL.Path,
+			IdempotencyKey: req.Header.Get("Idempotency-Key"),
+			At:             time.Now(),
+		}
+		if len(body) > 0 {
+			rec.Body = json.RawMessage(body)
+		}
+		v, status, err := d.apply(next, pol, req, base, kind, id, body)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return d.synthesize(req, status, v), nil
+		}
+		resp := d.synthesize(req, http.StatusOK, v)
+		rec.Response, _ = json.Marshal(v)
+		d.mu.Lock()
+		d.requests = append(d.requests, rec)
+		d.mu.Unlock()
+		return resp, nil
+	})
+}
+
+func (d *DryRun) lookup(kind, id string) (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if kind == charges {
+		c, ok := d.charges[id]
+		if ok {
+			cp := *c
+			return &cp, true
+		}
+		return nil, false
+	}
+	r, ok := d.refunds[id]
+	if ok {
+		cp := *r
+		return &cp, true
+	}
+	return nil, false
+}
+
+//apply computes the object the API would answer with, status is not 200 when the call would fail
+func (d *DryRun) apply(next Doer, pol *IntegrityPolicy, req *http.Request, base, kind, id string, body []byte) (interface{}, int, error) {
+	switch {
+	case req.Method == http.MethodPost && id == "" && kind == charges:
+		c := new(Charge)
+		if err := json.Unmarshal(body, c); err != nil || c.UserID == "" || c.Amount == 0 {
+			return dryRunError(36, "Invalid charge"), http.StatusBadRequest, nil
+		}
+		c.ID = generateUUID()
+		c.Status = Required
+		expire := 15 * time.Minute
+		if c.ExpireIn > 0 {
+			expire = time.Duration(c.ExpireIn) * time.Second
+		}
+		c.ExpireDate = putTime(time.Now().Add(expire).UTC())
+		d.mu.Lock()
+		d.charges[c.ID] = c
+		d.mu.Unlock()
+		return c, http.StatusOK, nil
+	case req.Method == http.MethodPost && id == "" && kind == refunds:
+		r := new(Refund)
+		if err := json.Unmarshal(body, r); err != nil || r.ChargeID == "" || r.Amount == 0 {
+			return dryRunError(36, "Invalid refund"), http.StatusBadRequest, nil
+		}
+		c, status, err := d.current(next, pol, req, base, charges, r.ChargeID)
+		if err != nil || status != http.StatusOK {
+			return c, status, err
+		}
+		ch := c.(*Charge)
+		if ch.Status != Success || ch.Refund+r.Amount > ch.Amount {
+			return dryRunError(36, "Charge cannot be refunded"), http.StatusBadRequest, nil
+		}
+		ch.Refund += r.Amount
+		r.ID = generateUUID()
+		r.Created = putTime(time.Now().UTC())
+		d.mu.Lock()
+		d.charges[ch.ID] = ch
+		d.refunds[r.ID] = r
+		d.mu.Unlock()
+		return r, http.StatusOK, nil
+	case req.Method == http.MethodPut && id != "":
+		v, status, err := d.current(next, pol, req, base, kind, id)
+		if err != nil || status != http.StatusOK {
+			return v, status, err
+		}
+		var update struct {
+			State       string            `json:"charge_state"`
+			Description *string           `json:"description"`
+			Metadata    map[string]string `json:"metadata"`
+		}
+		if err := json.Unmarshal(body, &update); err != nil {
+			return dryRunError(36, "Invalid body"), http.StatusBadRequest, nil
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if r, ok := v.(*Refund); ok {
+			if update.Metadata != nil {
+				r.Metadata = update.Metadata
+			}
+			d.refunds[r.ID] = r
+			return r, http.StatusOK, nil
+		}
+		c := v.(*Charge)
+		if update.State == Canceled {
+			if c.Status != Required {
+				return dryRunError(36, "Charge cannot be canceled"), http.StatusBadRequest, nil
+			}
+			c.Status = Failure
+			c.StatusDetails = Canceled
+		}
+		if update.Description != nil {
+			c.Description = *update.Description
+		}
+		if update.Metadata != nil {
+			c.Metadata = update.Metadata
+		}
+		d.charges[c.ID] = c
+		return c, http.StatusOK, nil
+	}
+	return dryRunError(41, "Not found"), http.StatusNotFound, nil
+}
+
+//current returns the object as recorded by d or, when d does not know it, as read from the API
+func (d *DryRun) current(next Doer, pol *IntegrityPolicy, req *http.Request, base, kind, id string) (interface{}, int, error) {
+	if v, ok := d.lookup(kind, id); ok {
+		return v, http.StatusOK, nil
+	}
+	get, err := http.NewRequest(http.MethodGet, req.URL.Scheme+"://"+req.URL.Host+base+kind+"/"+id, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	get = get.WithContext(req.Context())
+	get.Header.Set("Authorization", req.Header.Get("Authorization"))
+	get.Header.Set("Content-Type", "application/json")
+	resp, err := next.Do(get)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := checkIntegrity(resp, pol)
+	if err != nil {
+		return nil, 0, err
+	}
+	var v interface{} = new(Charge)
+	if kind == refunds {
+		v = new(Refund)
+	}
+	if resp.StatusCode != http.StatusOK {
+		v = json.RawMessage(body)
+	} else if err := json.Unmarshal(body, v); err != nil {
+		return nil, 0, fmt.Errorf("Error unmarshaling the current object: %s", err.Error())
+	}
+	return v, resp.StatusCode, nil
+}
+
+func dryRunError(code int, msg string) interface{} {
+	return map[string]interface{}{"code": code, "message": msg}
+}
+
+//synthesize builds a response with the headers checked by checkIntegrity
+func (d *DryRun) synthesize(req *http.Request, status int, v interface{}) *http.Response {
+	if ci, ok := req.Context().Value(callInfoKey{}).(*callInfo); ok {
+		ci.synthetic = true
+	}
+	body, _ := json.Marshal(v)
+	sum := sha512.Sum512(body)
+	h := make(http.Header)
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	h.Set("Digest", "SHA-512="+base64.StdEncoding.EncodeToString(sum[:]))
+	h.Set("X-Satispay-Cid", generateUUID())
+	h.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
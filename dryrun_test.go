@@ -0,0 +1,109 @@
+package satisgo_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestDryRun(t *testing.T) {
+	for _, base := range []string{"", "/api"} {
+		t.Run("base "+base, func(t *testing.T) {
+			srv, real, u := newTestServer(t)
+			ts := httptest.NewServer(http.StripPrefix(base, srv.Config.Handler))
+			defer ts.Close()
+			pending := newTestCharge(t, srv, real, u, 500, satisgo.Required, map[string]string{"order_id": "A"})
+			paid := newTestCharge(t, srv, real, u, 700, satisgo.Success, nil)
+
+			d := satisgo.NewDryRun()
+			p, err := satisgo.New(srv.Bearer, "staging", satisgo.WithBaseURL(ts.URL+base), satisgo.WithDryRun(d))
+			if err != nil {
+				t.Fatal(err)
+			}
+			created, err := p.Charges.Create(satisgo.ChargeCreateParams{
+				UserID:      u.ID,
+				Amount:      300,
+				CallbackURL: "https://example.com/callback?charge_id={uuid}",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			canceled, err := p.Charges.Cancel(pending.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if canceled.Status != satisgo.Failure || canceled.StatusDetails != satisgo.Canceled {
+				t.Fatalf("unexpected canceled charge %+v", canceled)
+			}
+			_, err = p.Charges.Update(pending.ID, satisgo.ChargeUpdateParams{Metadata: map[string]string{"order_id": "B"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: paid.ID, Amount: 200})
+			if err != nil {
+				t.Fatal(err)
+			}
+			//the dry run answers the reads of what it has changed
+			got, err := p.Charges.Get(created.ID)
+			if err != nil || got.Amount != 300 {
+				t.Fatalf("got %+v, %v", got, err)
+			}
+			got, err = p.Charges.Get(paid.ID)
+			if err != nil || got.Refund != 200 {
+				t.Fatalf("got %+v, %v, want the refund of the dry run", got, err)
+			}
+
+			methods := make([]string, 0, 4)
+			for _, rec := range d.Requests() {
+				if !strings.HasPrefix(rec.Path, base+"/online/v1/") {
+					t.Errorf("unexpected path %s", rec.Path)
+				}
+				methods = append(methods, rec.Method)
+			}
+			if got := strings.Join(methods, " "); got != "POST PUT PUT POST" {
+				t.Fatalf("got recorded requests %q", got)
+			}
+			//nothing changed on the API
+			charges := srv.Charges()
+			if len(charges) != 2 {
+				t.Fatalf("got %d charges on the API, want 2", len(charges))
+			}
+			if charges[0].Status != satisgo.Required || charges[0].Metadata["order_id"] != "A" || charges[1].Refund != 0 {
+				t.Fatalf("the charges on the API should not change: %+v", charges)
+			}
+			if list, _ := real.Refunds.List(nil); len(list) != 0 || r.ID == "" {
+				t.Fatalf("got refunds %+v on the API", list)
+			}
+		})
+	}
+}
+
+func TestDryRunUnknownCall(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	reroute := func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.URL.Path = strings.Replace(req.URL.Path, "/users", "/payments", 1)
+			return next.Do(req)
+		})
+	}
+	d := satisgo.NewDryRun()
+	p, err := srv.Client(satisgo.WithDryRun(d), satisgo.WithMiddleware(reroute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.UserFromPhone(testPhone)
+	if err == nil || !strings.Contains(err.Error(), "not been sent") {
+		t.Fatalf("got %v, want an unknown POST refused", err)
+	}
+	//the lookup of a user is a read
+	p, err = srv.Client(satisgo.WithDryRun(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.UserFromPhone(testPhone); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,52 @@
 package satisgo
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+)
+
+//APIError is returned when the API answers with an error status, use errors.As to get it
+type APIError struct {
+	StatusCode int
+	//Code and Message are the error of the body of the response, when it has one
+	Code    int64
+	Message string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%d", e.StatusCode)
+	if err := handleHeader(e.StatusCode); err != nil {
+		msg = err.Error()
+	}
+	if e.Message == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s --> CODE %d: %s", msg, e.Code, e.Message)
+}
+
+//newAPIError parses the error code and message of the body
+func newAPIError(status int, body []byte) *APIError {
+	e := &APIError{StatusCode: status}
+	code, err := jsonparser.GetInt(body, "code")
+	if err != nil {
+		return e
+	}
+	msg, err := jsonparser.GetString(body, "message")
+	if err != nil {
+		return e
+	}
+	e.Code = code
+	e.Message = msg
+	return e
+}
+
+//IsNotFound is true when err comes from a 404 answer of the API
+func IsNotFound(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.StatusCode == 404
+}
 
 func handleHeader(header int) error {
 	switch header {
@@ -16,6 +62,8 @@ func handleHeader(header int) error {
 		return fmt.Errorf("%d -- Forbidden – The resource requested is hidden for administrators only", header)
 	case 404:
 		return fmt.Errorf("%d -- Not Found – The specified resource could not be found", header)
+	case 429:
+		return fmt.Errorf("%d -- Too Many Requests – You are sending too many requests, slow down", header)
 	case 500:
 		return fmt.Errorf("%d -- Internal Server Error – We had a problem with our server. Try again later", header)
 	case 503:
@@ -0,0 +1,181 @@
+package satisgo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+//ChargeIndex maps metadata pairs to charge ids so charges can be found by an external reference
+//Implementations must be safe for concurrent use
+type ChargeIndex interface {
+	//Add records that the charge has the key/value pair in its metadata
+	Add(key, value, chargeID string) error
+	//Remove forgets the key/value pair for the charge
+	Remove(key, value, chargeID string) error
+	//Lookup returns the ids of the charges with the key/value pair
+	Lookup(key, value string) ([]string, error)
+}
+
+//chargeIndexer keeps the index up to date for the configured keys
+type chargeIndexer struct {
+	index ChargeIndex
+	keys  map[string]bool
+}
+
+//WithChargeIndex keeps idx updated every time CreateCharge and UpdateChargeMetadata succeed
+//Only the given metadata keys are indexed, all of them when none is given
+func WithChargeIndex(idx ChargeIndex, keys ...string) Option {
+	return func(p *Satis) error {
+		if idx == nil {
+			return fmt.Errorf("ChargeIndex cannot be nil")
+		}
+		ci := &chargeIndexer{index: idx}
+		if len(keys) > 0 {
+			ci.keys = make(map[string]bool, len(keys))
+			for _, k := range keys {
+				ci.keys[k] = true
+			}
+		}
+		p.index = ci
+		return nil
+	}
+}
+
+func (ci *chargeIndexer) indexed(key string) bool {
+	return ci.keys == nil || ci.keys[key]
+}
+
+//update reflects in the index the change of metadata from old to c
+func (ci *chargeIndexer) update(old map[string]string, c *Charge) error {
+	for k, v := range old {
+		if !ci.indexed(k) {
+			continue
+		}
+		if nv, ok := c.Metadata[k]; ok && nv == v {
+			continue
+		}
+		err := ci.index.Remove(k, v, c.ID)
+		if err != nil {
+			return err
+		}
+	}
+	for k, v := range c.Metadata {
+		if !ci.indexed(k) {
+			continue
+		}
+		err := ci.index.Add(k, v, c.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//indexCharge is called after a successful call: an index failure does not fail the call
+func (p *Satis) indexCharge(old map[string]string, c *Charge) {
+	if p.index == nil || c.ID == "" {
+		return
+	}
+	p.index.update(old, c)
+}
+
+//FindChargesByMetadata returns the charges having value for the metadata key (ex. "order_id")
+//When a ChargeIndex is configured for key it is used to fetch only the matching charges,
+//otherwise (or when the index knows nothing about the pair) all the charges are scanned.
+//The ids of the index that are not found on Satispay anymore are removed from it.
+//The result is best-effort when the index has a hit: the index only knows the charges created or
+//updated by this client and the ones found by an earlier scan, the others with the same pair are not returned.
+//Use p.Charges.List when every matching charge is needed
+func (p *Satis) FindChargesByMetadata(ctx context.Context, key, value string) (_ []Charge, err error) {
+	c, span := p.WithContext(ctx).startSpan("FindChargesByMetadata")
+	defer span.end(&err)
+	found := make([]Charge, 0)
+	if p.index != nil && p.index.indexed(key) {
+		ids, err := p.index.index.Lookup(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("Error looking up the charge index: %s", err.Error())
+		}
+		for _, id := range ids {
+			ch, err := c.Charges.Get(id)
+			if IsNotFound(err) {
+				//the charge is not known by Satispay anymore, the index is stale
+				p.index.index.Remove(key, value, id)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if v, ok := ch.Metadata[key]; ok && v == value {
+				found = append(found, *ch)
+				continue
+			}
+			p.index.index.Remove(key, value, id)
+		}
+		if len(found) > 0 {
+			return found, nil
+		}
+	}
+	all, err := c.allCharges()
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		ch := &all[i]
+		if v, ok := ch.Metadata[key]; ok && v == value {
+			found = append(found, *ch)
+			p.indexCharge(nil, ch)
+		}
+	}
+	return found, nil
+}
+
+//MemoryIndex is an in-memory ChargeIndex, lost when the process exits
+type MemoryIndex struct {
+	mu    sync.RWMutex
+	pairs map[string]map[string]bool
+}
+
+//NewMemoryIndex generates an empty MemoryIndex
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{pairs: make(map[string]map[string]bool)}
+}
+
+func memoryIndexKey(key, value string) string {
+	return key + "\x00" + value
+}
+
+//Add implements ChargeIndex
+func (m *MemoryIndex) Add(key, value, chargeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memoryIndexKey(key, value)
+	if m.pairs[k] == nil {
+		m.pairs[k] = make(map[string]bool)
+	}
+	m.pairs[k][chargeID] = true
+	return nil
+}
+
+//Remove implements ChargeIndex
+func (m *MemoryIndex) Remove(key, value, chargeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memoryIndexKey(key, value)
+	delete(m.pairs[k], chargeID)
+	if len(m.pairs[k]) == 0 {
+		delete(m.pairs, k)
+	}
+	return nil
+}
+
+//Lookup implements ChargeIndex
+func (m *MemoryIndex) Lookup(key, value string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.pairs[memoryIndexKey(key, value)]))
+	for id := range m.pairs[memoryIndexKey(key, value)] {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
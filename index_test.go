@@ -0,0 +1,85 @@
+package satisgo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestChargeIndexUpdate(t *testing.T) {
+	idx := satisgo.NewMemoryIndex()
+	srv, p, u := newTestServer(t, satisgo.WithChargeIndex(idx, "order_id"))
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, map[string]string{"order_id": "A"})
+
+	//the map is changed in place before the update, as SetMetadata does
+	c.SetMetadata("order_id", "B")
+	err := c.UpdateChargeMetadata(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := idx.Lookup("order_id", "A"); len(ids) != 0 {
+		t.Fatalf("order_id=A still indexed for %v", ids)
+	}
+	_, err = p.Charges.Update(c.ID, satisgo.ChargeUpdateParams{Metadata: map[string]string{"order_id": "C"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := idx.Lookup("order_id", "B"); len(ids) != 0 {
+		t.Fatalf("order_id=B still indexed for %v", ids)
+	}
+	for value, want := range map[string]int{"A": 0, "B": 0, "C": 1} {
+		found, err := p.FindChargesByMetadata(context.Background(), "order_id", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != want {
+			t.Errorf("order_id=%s: found %d charges, want %d", value, len(found), want)
+		}
+	}
+}
+
+func TestChargeIndexStale(t *testing.T) {
+	idx := satisgo.NewMemoryIndex()
+	srv, p, u := newTestServer(t, satisgo.WithChargeIndex(idx))
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, map[string]string{"order_id": "X"})
+	idx.Add("order_id", "X", "not-on-satispay")
+
+	found, err := p.FindChargesByMetadata(context.Background(), "order_id", "X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != c.ID {
+		t.Fatalf("unexpected charges %+v", found)
+	}
+	ids, _ := idx.Lookup("order_id", "X")
+	if len(ids) != 1 || ids[0] != c.ID {
+		t.Fatalf("the stale id should be removed from the index, got %v", ids)
+	}
+}
+
+func TestChargeListPartialIndex(t *testing.T) {
+	idx := satisgo.NewMemoryIndex()
+	srv, p, u := newTestServer(t, satisgo.WithChargeIndex(idx, "order_id"))
+	newTestCharge(t, srv, p, u, 500, satisgo.Required, map[string]string{"order_id": "A"})
+	//a client without the index: the charge is not indexed
+	other, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTestCharge(t, srv, other, u, 700, satisgo.Required, map[string]string{"order_id": "A"})
+	if ids, _ := idx.Lookup("order_id", "A"); len(ids) != 1 {
+		t.Fatalf("got %d indexed charges, want 1", len(ids))
+	}
+
+	list, err := p.Charges.List(&satisgo.ChargeListParams{MetadataKey: "order_id", MetadataValue: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("listed %d charges, want 2", len(list))
+	}
+	if ids, _ := idx.Lookup("order_id", "A"); len(ids) != 2 {
+		t.Fatalf("got %d indexed charges after the list, want 2", len(ids))
+	}
+}
@@ -0,0 +1,275 @@
+package satisgo
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+//IntegrityMode tells how a check on the headers of the responses is done
+type IntegrityMode int
+
+const (
+	//IntegrityRequire fails when the header is missing or wrong
+	IntegrityRequire IntegrityMode = iota
+	//IntegrityVerifyIfPresent fails only when the header is present and wrong (ex. chunked responses behind a proxy)
+	IntegrityVerifyIfPresent
+	//IntegrityOff skips the check
+	IntegrityOff
+)
+
+//Names of the checks, used in IntegrityError
+const (
+	CheckContentLength = "Content-Length"
+	CheckContentType   = "Content-Type"
+	CheckDigest        = "Digest"
+	CheckCid           = "X-Satispay-Cid"
+	CheckDate          = "Date"
+	CheckSignature     = "Signature"
+)
+
+//IntegrityPolicy configures the checks done on every response of the API
+//The zero value requires every check, DefaultIntegrityPolicy is the one used by New
+type IntegrityPolicy struct {
+	ContentLength IntegrityMode
+	ContentType   IntegrityMode
+	Digest        IntegrityMode
+	Cid           IntegrityMode
+	Date          IntegrityMode
+	//MaxSkew is the largest difference allowed between the Date header and Clock (5 minutes by default)
+	MaxSkew time.Duration
+	//Clock gives the current time, time.Now by default
+	Clock func() time.Time
+	//DigestAlgorithms are the algorithms accepted in the Digest header (SHA-256 and SHA-512 by default)
+	DigestAlgorithms []string
+}
+
+//DefaultIntegrityPolicy requires every header sent by Satispay, the Date is not checked
+//unless a SignatureVerifier is given (see WithSignatureVerifier)
+var DefaultIntegrityPolicy = IntegrityPolicy{
+	Date: IntegrityOff,
+}
+
+//digestAlgorithms are the algorithms supported in the Digest header
+var digestAlgorithms = map[string]func() hash.Hash{
+	"SHA-256": sha256.New,
+	"SHA-512": sha512.New,
+}
+
+//IntegrityError is returned when a response does not pass a check of the IntegrityPolicy
+type IntegrityError struct {
+	//Check is the name of the failed check (ex. CheckDigest)
+	Check  string
+	Reason string
+}
+
+func (e *IntegrityError) Error() string {
+	return e.Check + " " + e.Reason
+}
+
+//WithIntegrityPolicy replaces DefaultIntegrityPolicy for the responses of the API
+func WithIntegrityPolicy(pol IntegrityPolicy) Option {
+	return func(p *Satis) error {
+		if pol.MaxSkew < 0 {
+			return fmt.Errorf("MaxSkew cannot be negative")
+		}
+		for _, a := range pol.DigestAlgorithms {
+			if _, ok := digestAlgorithms[strings.ToUpper(a)]; !ok {
+				return fmt.Errorf("Digest algorithm %s is not supported", a)
+			}
+		}
+		p.integrity = &pol
+		return nil
+	}
+}
+
+func integrityErr(check, format string, a ...interface{}) error {
+	return &IntegrityError{Check: check, Reason: fmt.Sprintf(format, a...)}
+}
+
+//header returns the only value of the header, ok is false when it is missing and the mode allows it
+func header(h http.Header, check string, mode IntegrityMode) (_ string, ok bool, _ error) {
+	values, present := h[http.CanonicalHeaderKey(check)]
+	if !present {
+		if mode == IntegrityRequire {
+			return "", false, integrityErr(check, "value in header does not exist")
+		}
+		return "", false, nil
+	}
+	if len(values) != 1 {
+		return "", false, integrityErr(check, "has multiple values in header")
+	}
+	return values[0], true, nil
+}
+
+func checkIntegrity(r *http.Response, pol *IntegrityPolicy) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if pol.Date != IntegrityOff {
+		err = pol.checkDate(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.StatusCode == 204 && len(body) == 0 {
+		//a 204 has no body and must not have a Content-Length (RFC 7230)
+		return []byte(""), nil
+	}
+	if pol.ContentLength != IntegrityOff {
+		err = checkContentLength(r, pol.ContentLength, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.StatusCode == 204 {
+		return []byte(""), nil
+	}
+	if pol.ContentType != IntegrityOff {
+		err = checkContentType(r, pol.ContentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pol.Digest != IntegrityOff {
+		err = pol.checkDigest(r.Header, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pol.Cid != IntegrityOff {
+		err = checkCid(r, pol.Cid, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	//The Server header is not checked: its value is not documented by Satispay
+	return body, nil
+}
+
+func checkContentLength(r *http.Response, mode IntegrityMode, body []byte) error {
+	v, ok, err := header(r.Header, CheckContentLength, mode)
+	if err != nil || !ok {
+		return err
+	}
+	l, err := strconv.Atoi(v)
+	if err != nil {
+		return integrityErr(CheckContentLength, "value in header is not a number")
+	}
+	if len(body) != l {
+		return integrityErr(CheckContentLength, "value in header is not true")
+	}
+	return nil
+}
+
+func checkContentType(r *http.Response, mode IntegrityMode) error {
+	v, ok, err := header(r.Header, CheckContentType, mode)
+	if err != nil || !ok {
+		return err
+	}
+	t, _, err := mime.ParseMediaType(v)
+	if err != nil || t != "application/json" {
+		return integrityErr(CheckContentType, "value in header is not correct")
+	}
+	return nil
+}
+
+//checkDigest verifies every supported algorithm of the Digest header (ex. "SHA-256=...,SHA-512=...")
+func (pol *IntegrityPolicy) checkDigest(h http.Header, body []byte) error {
+	v, ok, err := header(h, CheckDigest, pol.Digest)
+	if err != nil || !ok {
+		return err
+	}
+	checked := 0
+	for _, d := range strings.Split(v, ",") {
+		i := strings.Index(d, "=")
+		if i < 0 {
+			return integrityErr(CheckDigest, "value in header is malformed")
+		}
+		alg := strings.ToUpper(strings.TrimSpace(d[:i]))
+		if !pol.acceptsDigest(alg) {
+			continue
+		}
+		sum := digestAlgorithms[alg]()
+		sum.Write(body)
+		if strings.TrimSpace(d[i+1:]) != base64.StdEncoding.EncodeToString(sum.Sum(nil)) {
+			return integrityErr(CheckDigest, "is incorrect (%s)", alg)
+		}
+		checked++
+	}
+	if checked == 0 {
+		return integrityErr(CheckDigest, "has no accepted algorithm")
+	}
+	return nil
+}
+
+func (pol *IntegrityPolicy) acceptsDigest(alg string) bool {
+	if _, ok := digestAlgorithms[alg]; !ok {
+		return false
+	}
+	if len(pol.DigestAlgorithms) == 0 {
+		return true
+	}
+	for _, a := range pol.DigestAlgorithms {
+		if strings.ToUpper(a) == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func checkCid(r *http.Response, mode IntegrityMode, body []byte) error {
+	cid, ok, err := header(r.Header, CheckCid, mode)
+	if err != nil || !ok {
+		return err
+	}
+	w, err := jsonparser.GetString(body, "wlt")
+	if err != nil {
+		if err == jsonparser.KeyPathNotFoundError {
+			return nil
+		}
+		return integrityErr(CheckCid, "cannot be compared, error parsing WLT from body: %s", err.Error())
+	}
+	if w != cid {
+		return integrityErr(CheckCid, "does not match the WLT of the body")
+	}
+	return nil
+}
+
+func (pol *IntegrityPolicy) checkDate(r *http.Response) error {
+	v, ok, err := header(r.Header, CheckDate, pol.Date)
+	if err != nil || !ok {
+		return err
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return integrityErr(CheckDate, "value in header is not a valid date")
+	}
+	now := time.Now
+	if pol.Clock != nil {
+		now = pol.Clock
+	}
+	skew := pol.MaxSkew
+	if skew == 0 {
+		skew = 5 * time.Minute
+	}
+	d := now().Sub(t)
+	if d < 0 {
+		d = -d
+	}
+	if d > skew {
+		return integrityErr(CheckDate, "value in header is %s away from the clock", d.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package satisgo_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//tamper changes the headers of the answers about a charge
+func tamper(change func(h http.Header)) satisgo.Middleware {
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.Do(req)
+			if err == nil && req.Method == http.MethodGet {
+				change(resp.Header)
+			}
+			return resp, err
+		})
+	}
+}
+
+func TestIntegrityPolicy(t *testing.T) {
+	noDigest := func(h http.Header) { h.Del("Digest") }
+	badDigest := func(h http.Header) { h.Set("Digest", "SHA-512=AAAA") }
+	noCid := func(h http.Header) { h.Del("X-Satispay-Cid") }
+	late := func() time.Time { return time.Now().Add(time.Hour) }
+	tests := []struct {
+		name   string
+		pol    *satisgo.IntegrityPolicy
+		change func(h http.Header)
+		check  string
+	}{
+		{"default", nil, func(h http.Header) {}, ""},
+		{"missing digest", nil, noDigest, satisgo.CheckDigest},
+		{"wrong digest", nil, badDigest, satisgo.CheckDigest},
+		{"missing digest if present", &satisgo.IntegrityPolicy{Digest: satisgo.IntegrityVerifyIfPresent, Date: satisgo.IntegrityOff}, noDigest, ""},
+		{"wrong digest if present", &satisgo.IntegrityPolicy{Digest: satisgo.IntegrityVerifyIfPresent, Date: satisgo.IntegrityOff}, badDigest, satisgo.CheckDigest},
+		{"wrong digest off", &satisgo.IntegrityPolicy{Digest: satisgo.IntegrityOff, Date: satisgo.IntegrityOff}, badDigest, ""},
+		{"missing cid", nil, noCid, satisgo.CheckCid},
+		{"date skew", &satisgo.IntegrityPolicy{Clock: late}, func(h http.Header) {}, satisgo.CheckDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []satisgo.Option{satisgo.WithMiddleware(tamper(tt.change))}
+			if tt.pol != nil {
+				opts = append(opts, satisgo.WithIntegrityPolicy(*tt.pol))
+			}
+			srv, plain, u := newTestServer(t)
+			c := newTestCharge(t, srv, plain, u, 500, satisgo.Required, nil)
+			p, err := srv.Client(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.GetCharge(c.ID)
+			if tt.check == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var ie *satisgo.IntegrityError
+			if !errors.As(err, &ie) || ie.Check != tt.check {
+				t.Fatalf("got %v, want a failed %s check", err, tt.check)
+			}
+		})
+	}
+}
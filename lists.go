@@ -10,34 +10,22 @@ import (
 )
 
 //GetRefundFromChargeID returns all charges from the beginning
+//
+//Deprecated: use p.Refunds.List with RefundListParams.ChargeID
 func (p *Satis) GetRefundFromChargeID(chargeID string) (*[]Refund, error) {
-	total := make([]Refund, 0, 100)
-	temp := make([]Refund, 0, 100)
-	last := ""
-	stopper := true
-	for stopper {
-		q := url.Values{}
-		q.Set("limit", "100")
-		q.Set("charge_id", chargeID)
-		if last != "" {
-			q.Set("starting_after", last)
-		}
-		query := q.Encode()
-		more, err := p.getList(&temp, p.refundsURL(), query)
-		if err != nil {
-			return nil, err
-		}
-		total = append(total, temp...)
-		stopper = more
-		if len(temp) > 0 {
-			last = temp[len(temp)-1].ID
-		}
+	list, err := p.Refunds.List(&RefundListParams{ChargeID: chargeID})
+	if err != nil {
+		return nil, err
 	}
-	return &total, nil
+	return &list, nil
 }
 
 //GetRefundSinceChargeID returns all charges from the beginning
-func (p *Satis) GetRefundSinceChargeID(chargeID string) (*[]Refund, error) {
+//
+//Deprecated: use p.Refunds.List
+func (p *Satis) GetRefundSinceChargeID(chargeID string) (_ *[]Refund, err error) {
+	p, span := p.startSpan("GetRefundSinceChargeID", attrString(AttrChargeID, chargeID))
+	defer span.end(&err)
 	total := make([]Refund, 0, 100)
 	temp := make([]Refund, 0, 100)
 	last := "chargeID"
@@ -59,11 +47,51 @@ func (p *Satis) GetRefundSinceChargeID(chargeID string) (*[]Refund, error) {
 			last = temp[len(temp)-1].ID
 		}
 	}
+	span.set(attrInt(AttrCount, int64(len(total))))
 	return &total, nil
 }
 
 //GetAllRefunds returns all charges from the beginning
+//
+//Deprecated: use p.Refunds.List
 func (p *Satis) GetAllRefunds() (*[]Refund, error) {
+	list, err := p.Refunds.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+//GetAllUsers returns all charges from the beginning
+//
+//Deprecated: use p.Users.List
+func (p *Satis) GetAllUsers() (*[]User, error) {
+	list, err := p.Users.List()
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+//GetAllCharges returns all charges from the beginning
+//
+//Deprecated: use p.Charges.List
+func (p *Satis) GetAllCharges() (*[]Charge, error) {
+	list, err := p.Charges.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+//allRefunds returns all the refunds from the beginning, only the ones of chargeID when it is not empty
+func (p *Satis) allRefunds(chargeID string) (_ []Refund, err error) {
+	name, attrs := "GetAllRefunds", []Attribute(nil)
+	if chargeID != "" {
+		name, attrs = "GetRefundFromChargeID", []Attribute{attrString(AttrChargeID, chargeID)}
+	}
+	p, span := p.startSpan(name, attrs...)
+	defer span.end(&err)
 	total := make([]Refund, 0, 100)
 	temp := make([]Refund, 0, 100)
 	last := ""
@@ -71,6 +99,9 @@ func (p *Satis) GetAllRefunds() (*[]Refund, error) {
 	for stopper {
 		q := url.Values{}
 		q.Set("limit", "100")
+		if chargeID != "" {
+			q.Set("charge_id", chargeID)
+		}
 		if last != "" {
 			q.Set("starting_after", last)
 		}
@@ -85,11 +116,14 @@ func (p *Satis) GetAllRefunds() (*[]Refund, error) {
 			last = temp[len(temp)-1].ID
 		}
 	}
-	return &total, nil
+	span.set(attrInt(AttrCount, int64(len(total))))
+	return total, nil
 }
 
-//GetAllUsers returns all charges from the beginning
-func (p *Satis) GetAllUsers() (*[]User, error) {
+//allUsers returns all the users from the beginning
+func (p *Satis) allUsers() (_ []User, err error) {
+	p, span := p.startSpan("GetAllUsers")
+	defer span.end(&err)
 	total := make([]User, 0, 100)
 	temp := make([]User, 0, 100)
 	last := ""
@@ -107,13 +141,18 @@ func (p *Satis) GetAllUsers() (*[]User, error) {
 		}
 		total = append(total, temp...)
 		stopper = more
-		last = temp[len(temp)-1].ID
+		if len(temp) > 0 {
+			last = temp[len(temp)-1].ID
+		}
 	}
-	return &total, nil
+	span.set(attrInt(AttrCount, int64(len(total))))
+	return total, nil
 }
 
-//GetAllCharges returns all charges from the beginning
-func (p *Satis) GetAllCharges() (*[]Charge, error) {
+//allCharges returns all the charges from the beginning
+func (p *Satis) allCharges() (_ []Charge, err error) {
+	p, span := p.startSpan("GetAllCharges")
+	defer span.end(&err)
 	total := make([]Charge, 0, 100)
 	temp := make([]Charge, 0, 100)
 	last := new(Charge)
@@ -131,13 +170,18 @@ func (p *Satis) GetAllCharges() (*[]Charge, error) {
 		}
 		total = append(total, temp...)
 		stopper = more
-		last.ID = temp[len(temp)-1].ID
+		if len(temp) > 0 {
+			last.ID = temp[len(temp)-1].ID
+		}
 	}
-	return &total, nil
+	span.set(attrInt(AttrCount, int64(len(total))))
+	return total, nil
 }
 
 //getList is used to manage general lists in the satispay API. the bool in the return indicates if there are more where this came from
-func (p *Satis) getList(list interface{}, baseURL, query string) (bool, error) {
+func (p *Satis) getList(list interface{}, baseURL, query string) (_ bool, err error) {
+	p, span := p.startSpan("list.page")
+	defer span.end(&err)
 	//maybe some checking into the baseURL and query string can be done but since this is an internal function will leave it be wild nad young
 	var uri string
 	if baseURL == "" {
@@ -154,7 +198,7 @@ func (p *Satis) getList(list interface{}, baseURL, query string) (bool, error) {
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return false, fmt.Errorf("Error making the call to API: %s", err.Error())
+		return false, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return false, fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -171,5 +215,6 @@ func (p *Satis) getList(list interface{}, baseURL, query string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	span.set(attrString(AttrEndpoint, endpointOf(p.basePath(), r.URL.Path)), Attribute{Key: AttrHasMore, Value: hasMore})
 	return hasMore, nil
 }
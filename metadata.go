@@ -0,0 +1,238 @@
+package satisgo
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	//MetadataMaxPairs is the max number of key value pairs of a charge or refund
+	MetadataMaxPairs = 20
+	//MetadataMaxKeyLen is the max length of a metadata key
+	MetadataMaxKeyLen = 45
+	//MetadataMinKeyLen is the min length of a metadata key
+	MetadataMinKeyLen = 2
+	//MetadataMaxValueLen is the max length of a metadata value
+	MetadataMaxValueLen = 500
+)
+
+const (
+	metadataTag          = "metadata"
+	metadataOptOmitEmpty = "omitempty"
+)
+
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+//checkMetadataPair validates a single key/value pair against the Satispay limits
+func checkMetadataPair(key, value string) error {
+	if len(key) > MetadataMaxKeyLen || len(key) < MetadataMinKeyLen {
+		return fmt.Errorf("Metadata key %q has the wrong format: it must be %d to %d chars long", key, MetadataMinKeyLen, MetadataMaxKeyLen)
+	}
+	if len(value) > MetadataMaxValueLen {
+		return fmt.Errorf("Metadata value of %q is too long: %d chars, max %d", key, len(value), MetadataMaxValueLen)
+	}
+	return nil
+}
+
+//validateMetadata checks a whole metadata map before sending it to the API
+func validateMetadata(m map[string]string) error {
+	if len(m) > MetadataMaxPairs {
+		return fmt.Errorf("Metadata is too long: %d pairs, max %d", len(m), MetadataMaxPairs)
+	}
+	for k, v := range m {
+		err := checkMetadataPair(k, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//setMetadata adds the pair to m (allocating it if needed), an empty value deletes the key
+func setMetadata(m map[string]string, key, value string) (map[string]string, error) {
+	err := checkMetadataPair(key, value)
+	if err != nil {
+		return m, err
+	}
+	if m == nil {
+		m = make(map[string]string)
+	}
+	_, exist := m[key]
+	if value == "" {
+		delete(m, key)
+		return m, nil
+	}
+	if len(m) >= MetadataMaxPairs && !exist {
+		return m, fmt.Errorf("Metadata is too long already: cannot add %q, max %d pairs", key, MetadataMaxPairs)
+	}
+	m[key] = value
+	return m, nil
+}
+
+//encodeMetadata merges the tagged fields of the struct v into a copy of m
+//Fields are mapped with the `metadata:"key[,omitempty]"` tag, untagged fields are ignored.
+//Zero values with omitempty delete the key.
+func encodeMetadata(m map[string]string, v interface{}) (map[string]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("Metadata struct is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Metadata must be encoded from a struct, got %s", rv.Kind())
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		out[k] = val
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		key, omitEmpty, ok := parseMetadataTag(f)
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		value := ""
+		if !(omitEmpty && fv.IsZero()) {
+			s, err := formatMetadataValue(fv)
+			if err != nil {
+				return nil, fmt.Errorf("Metadata field %s: %s", f.Name, err.Error())
+			}
+			value = s
+		}
+		var err error
+		out, err = setMetadata(out, key, value)
+		if err != nil {
+			return nil, fmt.Errorf("Metadata field %s: %s", f.Name, err.Error())
+		}
+	}
+	return out, nil
+}
+
+//decodeMetadata fills the tagged fields of the struct pointed by v with the values in m
+//Keys missing from m leave the field untouched.
+func decodeMetadata(m map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Metadata must be decoded into a non nil pointer to struct")
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("Metadata must be decoded into a struct, got %s", rv.Kind())
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		key, _, ok := parseMetadataTag(f)
+		if !ok {
+			continue
+		}
+		s, exist := m[key]
+		if !exist {
+			continue
+		}
+		err := parseMetadataValue(rv.Field(i), s)
+		if err != nil {
+			return fmt.Errorf("Metadata key %q into field %s: %s", key, f.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func parseMetadataTag(f reflect.StructField) (string, bool, bool) {
+	if f.PkgPath != "" {
+		return "", false, false
+	}
+	tag, ok := f.Tag.Lookup(metadataTag)
+	if !ok || tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	omitEmpty := false
+	for _, o := range parts[1:] {
+		if o == metadataOptOmitEmpty {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, parts[0] != ""
+}
+
+func formatMetadataValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(textMarshaler) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshaler) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("type %s is not supported", v.Type())
+}
+
+func parseMetadataValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("type %s is not supported", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package satisgo_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+type orderMetadata struct {
+	OrderID  int       `metadata:"order_id"`
+	Customer string    `metadata:"customer,omitempty"`
+	Gift     bool      `metadata:"gift"`
+	Placed   time.Time `metadata:"placed"`
+	Note     *string   `metadata:"note,omitempty"`
+}
+
+func TestMetadataStruct(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, map[string]string{"customer": "old", "other": "kept"})
+	placed := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	err := c.SetMetadataStruct(orderMetadata{OrderID: 42, Gift: true, Placed: placed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Metadata["customer"]; ok {
+		t.Fatal("empty field with omitempty should delete the key")
+	}
+	c, err = p.Charges.Update(c.ID, satisgo.ChargeUpdateParams{Metadata: c.Metadata})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = p.Charges.Get(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata["order_id"] != "42" || c.Metadata["gift"] != "true" || c.Metadata["other"] != "kept" {
+		t.Fatalf("unexpected metadata %v", c.Metadata)
+	}
+	var got orderMetadata
+	err = c.DecodeMetadata(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OrderID != 42 || !got.Gift || !got.Placed.Equal(placed) || got.Note != nil {
+		t.Fatalf("unexpected decoded metadata %+v", got)
+	}
+}
+
+func TestMetadataLimits(t *testing.T) {
+	c := new(satisgo.Charge)
+	if err := c.SetMetadata("k", "v"); err == nil {
+		t.Fatal("a key shorter than 2 chars should be refused")
+	}
+	if err := c.SetMetadata("key", strings.Repeat("v", satisgo.MetadataMaxValueLen+1)); err == nil {
+		t.Fatal("a value too long should be refused")
+	}
+	for i := 0; i < satisgo.MetadataMaxPairs; i++ {
+		if err := c.SetMetadata("key"+strings.Repeat("x", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := len(c.Metadata)
+	if err := c.SetMetadataStruct(struct {
+		Extra string `metadata:"extra"`
+	}{"v"}); err == nil {
+		t.Fatal("the 21st pair should be refused")
+	}
+	if len(c.Metadata) != before {
+		t.Fatal("metadata should not change when a limit is exceeded")
+	}
+	var bad struct {
+		N int `metadata:"key"`
+	}
+	if err := c.DecodeMetadata(&bad); err == nil {
+		t.Fatal("a value that is not a number should not decode into an int")
+	}
+}
@@ -0,0 +1,95 @@
+package satisgo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+//Doer sends an HTTP request and returns the response, *http.Client is a Doer
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+//DoerFunc adapts a function to a Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+//Do implements Doer
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+//Middleware wraps the Doer sending the requests to the API
+//It sees the request once the headers are set and the response before the integrity checks
+type Middleware func(next Doer) Doer
+
+//WithMiddleware adds middlewares around the HTTP client, the first one is the outermost
+//The option can be given more than once, the middlewares are appended
+func WithMiddleware(mws ...Middleware) Option {
+	return func(p *Satis) error {
+		for _, mw := range mws {
+			if mw == nil {
+				return fmt.Errorf("Middleware cannot be nil")
+			}
+		}
+		p.middlewares = append(p.middlewares, mws...)
+		return nil
+	}
+}
+
+//chain wraps d with the middlewares of the client
+func (p *Satis) chain(d Doer) Doer {
+	for i := len(p.middlewares) - 1; i >= 0; i-- {
+		d = p.middlewares[i](d)
+	}
+	return d
+}
+
+//LoggingMiddleware logs method, path, status and duration of every call (never the headers)
+func LoggingMiddleware(l *log.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+			if err != nil {
+				l.Printf("satisgo: %s %s failed after %s: %s", req.Method, req.URL.Path, time.Since(start), err.Error())
+				return resp, err
+			}
+			l.Printf("satisgo: %s %s %d in %s", req.Method, req.URL.Path, resp.StatusCode, time.Since(start))
+			return resp, nil
+		})
+	}
+}
+
+type requestIDKey struct{}
+
+//ContextWithRequestID returns a copy of ctx carrying the id of the request being served
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+//RequestIDFromContext returns the id set with ContextWithRequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+//RequestIDMiddleware sends the request id of the context (see ContextWithRequestID) in header,
+//a new one is generated when the context has none. "X-Request-Id" is used when header is empty
+func RequestIDMiddleware(header string) Middleware {
+	if header == "" {
+		header = "X-Request-Id"
+	}
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			id, ok := RequestIDFromContext(req.Context())
+			if !ok {
+				id = generateUUID()
+			}
+			req.Header.Set(header, id)
+			return next.Do(req)
+		})
+	}
+}
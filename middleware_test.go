@@ -0,0 +1,90 @@
+package satisgo_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//trace is a middleware appending name to the calls it sees, before and after the next Doer
+func trace(mu *sync.Mutex, calls *[]string, name string) satisgo.Middleware {
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			*calls = append(*calls, name+">")
+			mu.Unlock()
+			resp, err := next.Do(req)
+			mu.Lock()
+			*calls = append(*calls, "<"+name)
+			mu.Unlock()
+			return resp, err
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	_, p, _ := newTestServer(t,
+		satisgo.WithMiddleware(trace(&mu, &calls, "a"), trace(&mu, &calls, "b")),
+		satisgo.WithMiddleware(trace(&mu, &calls, "c")),
+	)
+	_, err := p.UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(calls, " "); got != "a> b> c> <c <b <a" {
+		t.Fatalf("got %q, the first middleware should be the outermost", got)
+	}
+	if _, err := satisgo.New("token", "staging", satisgo.WithMiddleware(nil)); err == nil {
+		t.Fatal("a nil middleware should be refused")
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	srv, p, _ := newTestServer(t, satisgo.WithMiddleware(satisgo.LoggingMiddleware(log.New(&buf, "", 0))))
+	_, err := p.UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "POST /online/v1/users 200") {
+		t.Fatalf("unexpected log %q", out)
+	}
+	if strings.Contains(out, srv.Bearer) || strings.Contains(out, testPhone) {
+		t.Fatalf("the log should not contain the bearer or the phone number: %q", out)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	seen := func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			ids = append(ids, req.Header.Get("X-Trace"))
+			mu.Unlock()
+			return next.Do(req)
+		})
+	}
+	_, p, _ := newTestServer(t, satisgo.WithMiddleware(satisgo.RequestIDMiddleware("X-Trace"), seen))
+	ctx := satisgo.ContextWithRequestID(context.Background(), "req-1")
+	_, err := p.WithContext(ctx).UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "req-1" || ids[1] == "" || ids[1] == "req-1" {
+		t.Fatalf("got request ids %q, want req-1 then a generated one", ids)
+	}
+}
@@ -0,0 +1,90 @@
+package satisgo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	//EndpointAuth is the endpoint used by Verify
+	EndpointAuth = "auth"
+	//EndpointUsers is the endpoint of the users
+	EndpointUsers = "users"
+	//EndpointCharges is the endpoint of the charges
+	EndpointCharges = "charges"
+	//EndpointRefunds is the endpoint of the refunds
+	EndpointRefunds = "refunds"
+	//EndpointAmounts is the endpoint of the amounts
+	EndpointAmounts = "amounts"
+)
+
+//CallStats describes a single call to the API
+type CallStats struct {
+	Endpoint string
+	Method   string
+	//StatusCode is 0 when no response has been received
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+	//IntegrityFailure is true when the response did not pass the IntegrityPolicy or the SignatureVerifier
+	IntegrityFailure bool
+	//Retries is the number of attempts made before this one
+	Retries int
+}
+
+//Observer is notified of what happens inside the client, it is the hook for metrics
+//Implementations must be safe for concurrent use and must not block
+type Observer interface {
+	//ObserveCall is called after every call to the API
+	ObserveCall(CallStats)
+	//ObserveChargeEvent is called when a charge is created or seen changing status
+	ObserveChargeEvent(ChargeEvent)
+	//ObserveRefund is called when a refund has been created
+	ObserveRefund(Refund)
+}
+
+//WithObserver notifies o of every call, charge event and refund made by the client
+func WithObserver(o Observer) Option {
+	return func(p *Satis) error {
+		if o == nil {
+			return fmt.Errorf("Observer cannot be nil")
+		}
+		p.observer = o
+		return nil
+	}
+}
+
+//endpointOf returns the endpoint name of an API path, base is the path of the base URL of the client
+func endpointOf(base, path string) string {
+	path = strings.TrimPrefix(path, base)
+	switch {
+	case strings.HasPrefix(path, auth):
+		return EndpointAuth
+	case strings.HasPrefix(path, users):
+		return EndpointUsers
+	case strings.HasPrefix(path, charges):
+		return EndpointCharges
+	case strings.HasPrefix(path, refunds):
+		return EndpointRefunds
+	case strings.HasPrefix(path, ammounts):
+		return EndpointAmounts
+	}
+	return "unknown"
+}
+
+func (p *Satis) observeCall(req *http.Request, start time.Time, status, retries int, integrity bool, err error) {
+	if p.observer == nil {
+		return
+	}
+	p.observer.ObserveCall(CallStats{
+		Endpoint:         endpointOf(p.basePath(), req.URL.Path),
+		Method:           req.Method,
+		StatusCode:       status,
+		Duration:         time.Since(start),
+		Err:              err,
+		IntegrityFailure: integrity,
+		Retries:          retries,
+	})
+}
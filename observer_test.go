@@ -0,0 +1,119 @@
+package satisgo_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//recorder is an Observer keeping everything it is notified of
+type recorder struct {
+	mu      sync.Mutex
+	calls   []satisgo.CallStats
+	events  []satisgo.ChargeEvent
+	refunds []satisgo.Refund
+}
+
+func (r *recorder) ObserveCall(s satisgo.CallStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, s)
+}
+
+func (r *recorder) ObserveChargeEvent(e satisgo.ChargeEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) ObserveRefund(rf satisgo.Refund) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refunds = append(r.refunds, rf)
+}
+
+func TestObserver(t *testing.T) {
+	obs := new(recorder)
+	srv, p, u := newTestServer(t, satisgo.WithObserver(obs))
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, nil)
+	_, err := p.Charges.Cancel(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid := newTestCharge(t, srv, p, u, 700, satisgo.Success, nil)
+	_, err = p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: paid.ID, Amount: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Charges.Get("not-on-satispay")
+	if !satisgo.IsNotFound(err) {
+		t.Fatalf("got %v, want a not found error", err)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	want := []satisgo.ChargeEvent{
+		{ChargeID: c.ID, From: "", To: satisgo.Required},
+		{ChargeID: c.ID, From: satisgo.Required, To: satisgo.Failure, Details: satisgo.Canceled},
+		{ChargeID: paid.ID, From: "", To: satisgo.Required},
+	}
+	if len(obs.events) != len(want) {
+		t.Fatalf("got events %+v, want %d", obs.events, len(want))
+	}
+	for i, e := range obs.events {
+		if e.ChargeID != want[i].ChargeID || e.From != want[i].From || e.To != want[i].To || e.Details != want[i].Details {
+			t.Errorf("event %d: got %+v, want %+v", i, e, want[i])
+		}
+	}
+	if len(obs.refunds) != 1 || obs.refunds[0].Amount != 200 {
+		t.Fatalf("unexpected refunds %+v", obs.refunds)
+	}
+	var creates, notFound int
+	for _, s := range obs.calls {
+		if s.IntegrityFailure || s.Retries != 0 {
+			t.Errorf("unexpected call %+v", s)
+		}
+		if s.Endpoint == satisgo.EndpointCharges && s.Method == http.MethodPost && s.StatusCode == http.StatusOK {
+			creates++
+		}
+		if s.StatusCode == http.StatusNotFound {
+			notFound++
+		}
+	}
+	if creates != 2 || notFound != 1 {
+		t.Fatalf("got %d charges created and %d not found in %+v", creates, notFound, obs.calls)
+	}
+}
+
+func TestObserverBasePath(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	//the API is served under /api
+	front := httptest.NewServer(http.StripPrefix("/api", srv.Config.Handler))
+	t.Cleanup(front.Close)
+	obs := new(recorder)
+	p, err := satisgo.New(srv.Bearer, "staging", satisgo.WithBaseURL(front.URL+"/api"), satisgo.WithObserver(obs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Users.Lookup(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Charges.List(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	endpoints := make([]string, 0, len(obs.calls))
+	for _, c := range obs.calls {
+		endpoints = append(endpoints, c.Endpoint)
+	}
+	if len(endpoints) != 2 || endpoints[0] != satisgo.EndpointUsers || endpoints[1] != satisgo.EndpointCharges {
+		t.Fatalf("got endpoints %v, want [%s %s]", endpoints, satisgo.EndpointUsers, satisgo.EndpointCharges)
+	}
+}
@@ -0,0 +1,108 @@
+package satisgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//DefaultRegion is the region of the phone numbers written without the international prefix
+const DefaultRegion = "IT"
+
+//ErrInvalidPhone is wrapped by the errors of NormalizePhone
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+//region is the calling code of a country and the trunk prefix dropped from its national numbers
+type region struct {
+	code  string
+	trunk string
+}
+
+//regions are the countries whose national numbers can be normalized
+//Italy, San Marino and the Vatican keep the leading 0 of the landlines
+var regions = map[string]region{
+	"IT": {"39", ""},
+	"SM": {"378", ""},
+	"VA": {"39", ""},
+	"AT": {"43", "0"},
+	"BE": {"32", "0"},
+	"CH": {"41", "0"},
+	"DE": {"49", "0"},
+	"ES": {"34", ""},
+	"FR": {"33", "0"},
+	"GB": {"44", "0"},
+	"LU": {"352", ""},
+	"NL": {"31", "0"},
+	"PT": {"351", ""},
+	"US": {"1", "1"},
+}
+
+//NormalizePhone returns the phone number in the E.164 format (ex. "+393331234567")
+//Spaces, dots, dashes, slashes and parentheses are ignored, "00" is read as "+".
+//A number without the international prefix belongs to region (ex. "IT"), DefaultRegion when it is empty
+func NormalizePhone(phone, region string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case strings.ContainsRune(" .-/()", r):
+		default:
+			return "", fmt.Errorf("%w %s: unexpected character %q", ErrInvalidPhone, maskPhone(phone), r)
+		}
+	}
+	n := b.String()
+	switch {
+	case strings.HasPrefix(n, "+"):
+		n = n[1:]
+	case strings.HasPrefix(n, "00"):
+		n = n[2:]
+	default:
+		if region == "" {
+			region = DefaultRegion
+		}
+		reg, ok := regions[strings.ToUpper(region)]
+		if !ok {
+			return "", fmt.Errorf("%w %s: region %s is not supported", ErrInvalidPhone, maskPhone(phone), region)
+		}
+		if reg.trunk != "" {
+			n = strings.TrimPrefix(n, reg.trunk)
+		}
+		n = reg.code + n
+	}
+	//E.164 numbers have at most 15 digits, the calling code never starts with 0
+	if len(n) < 8 || len(n) > 15 {
+		return "", fmt.Errorf("%w %s: wrong number of digits", ErrInvalidPhone, maskPhone(phone))
+	}
+	if n[0] == '0' {
+		return "", fmt.Errorf("%w %s: wrong international prefix", ErrInvalidPhone, maskPhone(phone))
+	}
+	return "+" + n, nil
+}
+
+//maskPhone keeps only the last 2 digits of the phone number: the errors end up in logs and traces
+func maskPhone(phone string) string {
+	digits := make([]rune, 0, len(phone))
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) > 2 {
+		digits = digits[len(digits)-2:]
+	}
+	return "***" + string(digits)
+}
+
+//WithDefaultRegion sets the region of the phone numbers given without the international prefix (ex. "DE")
+func WithDefaultRegion(region string) Option {
+	return func(p *Satis) error {
+		if _, ok := regions[strings.ToUpper(region)]; !ok {
+			return fmt.Errorf("Region %s is not supported", region)
+		}
+		p.region = strings.ToUpper(region)
+		return nil
+	}
+}
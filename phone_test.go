@@ -0,0 +1,78 @@
+package satisgo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	valid := []struct {
+		phone, region, want string
+	}{
+		{"+39 333 123 4567", "", "+393331234567"},
+		{"0039 333-123-4567", "", "+393331234567"},
+		{"333.123.4567", "", "+393331234567"},
+		{"333 1234567", "it", "+393331234567"},
+		//the leading 0 of the italian landlines is kept
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"(030) 1234567", "DE", "+49301234567"},
+		{"0612345678", "FR", "+33612345678"},
+		{"1 (212) 555-0100", "US", "+12125550100"},
+		{"0549 123456", "SM", "+3780549123456"},
+	}
+	for _, tc := range valid {
+		got, err := satisgo.NormalizePhone(tc.phone, tc.region)
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizePhone(%q, %q) = %q, %v, want %q", tc.phone, tc.region, got, err, tc.want)
+		}
+	}
+	invalid := []struct {
+		phone, region string
+	}{
+		{"333 123 4567 ext. 2", ""},
+		{"33+31234567", ""},
+		{"123", ""},
+		{"+39333123456789012", ""},
+		{"+0393331234567", ""},
+		{"3331234567", "XX"},
+		{"", ""},
+	}
+	for _, tc := range invalid {
+		_, err := satisgo.NormalizePhone(tc.phone, tc.region)
+		if !errors.Is(err, satisgo.ErrInvalidPhone) {
+			t.Errorf("NormalizePhone(%q, %q): got %v, want ErrInvalidPhone", tc.phone, tc.region, err)
+		}
+	}
+}
+
+func TestUserFromPhoneNormalized(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	for _, phone := range []string{testPhone, "333 123 4567", "0039 (333) 123-4567"} {
+		got, err := p.UserFromPhone(phone)
+		if err != nil {
+			t.Fatalf("%q: %v", phone, err)
+		}
+		if got.ID != u.ID || got.Phone != testPhone {
+			t.Fatalf("%q: got %+v, want %+v", phone, got, u)
+		}
+	}
+	_, err := p.UserFromPhone("not a phone")
+	if !errors.Is(err, satisgo.ErrInvalidPhone) {
+		t.Fatalf("got %v, want ErrInvalidPhone", err)
+	}
+
+	de := srv.AddUser("+4915112345678")
+	p, err = srv.Client(satisgo.WithDefaultRegion("de"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.UserFromPhone("0151 12345678")
+	if err != nil || got.ID != de.ID {
+		t.Fatalf("got %+v, %v, want %+v", got, err, de)
+	}
+	if _, err := srv.Client(satisgo.WithDefaultRegion("XX")); err == nil {
+		t.Fatal("an unknown region should be refused")
+	}
+}
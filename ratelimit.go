@@ -0,0 +1,224 @@
+package satisgo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//RateLimit configures the limiter of an endpoint
+type RateLimit struct {
+	//Rate is the number of requests per second allowed (0 means no limit)
+	Rate float64
+	//Burst is the number of requests that can be sent at once (1 by default)
+	Burst int
+	//MaxInFlight is the number of requests waiting for a response at the same time (0 means no limit)
+	MaxInFlight int
+	//Retries is the number of times a request answered with 429 is sent again
+	Retries int
+}
+
+//WithRateLimit limits the requests sent to the endpoint (ex. EndpointCharges), an empty endpoint
+//sets the limit of all the endpoints without their own. The option can be given for each endpoint.
+//A 429 response pauses the endpoint for the time asked by its Retry-After header (1 second by default)
+func WithRateLimit(endpoint string, l RateLimit) Option {
+	return func(p *Satis) error {
+		if l.Rate < 0 || l.Burst < 0 || l.MaxInFlight < 0 || l.Retries < 0 {
+			return fmt.Errorf("rate limit values cannot be negative")
+		}
+		if l.Burst == 0 {
+			l.Burst = 1
+		}
+		if p.limiter == nil {
+			p.limiter = &limiter{limits: make(map[string]RateLimit), buckets: make(map[string]*bucket)}
+		}
+		p.limiter.limits[endpoint] = l
+		return nil
+	}
+}
+
+//limiter keeps a bucket for each endpoint of the API
+type limiter struct {
+	limits  map[string]RateLimit
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func (l *limiter) bucket(endpoint string) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[endpoint]
+	if ok {
+		return b
+	}
+	cfg, ok := l.limits[endpoint]
+	if !ok {
+		cfg = l.limits[""]
+	}
+	b = &bucket{cfg: cfg, tokens: float64(cfg.Burst), last: time.Now()}
+	if cfg.MaxInFlight > 0 {
+		b.sem = make(chan struct{}, cfg.MaxInFlight)
+	}
+	l.buckets[endpoint] = b
+	return b
+}
+
+//bucket is a token bucket with a semaphore for the requests in flight
+type bucket struct {
+	cfg         RateLimit
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	sem         chan struct{}
+}
+
+//wait blocks until a token is available or ctx is done
+func (b *bucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		var d time.Duration
+		switch {
+		case now.Before(b.pausedUntil):
+			d = b.pausedUntil.Sub(now)
+		case b.cfg.Rate <= 0:
+			b.mu.Unlock()
+			return nil
+		default:
+			b.tokens += now.Sub(b.last).Seconds() * b.cfg.Rate
+			if b.tokens > float64(b.cfg.Burst) {
+				b.tokens = float64(b.cfg.Burst)
+			}
+			b.last = now
+			if b.tokens >= 1 {
+				b.tokens--
+				b.mu.Unlock()
+				return nil
+			}
+			d = time.Duration((1 - b.tokens) / b.cfg.Rate * float64(time.Second))
+		}
+		b.mu.Unlock()
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+//pause stops the endpoint until t, the bucket is emptied
+func (b *bucket) pause(t time.Time) {
+	b.mu.Lock()
+	if t.After(b.pausedUntil) {
+		b.pausedUntil = t
+	}
+	b.tokens = 0
+	b.last = t
+	b.mu.Unlock()
+}
+
+func (b *bucket) acquire(ctx context.Context) error {
+	if b.sem == nil {
+		return nil
+	}
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *bucket) release() {
+	if b.sem != nil {
+		<-b.sem
+	}
+}
+
+//releaseBody frees the slot in flight when the body of the response is closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+//retryAfter reads the Retry-After header, in seconds or as a date
+func retryAfter(resp *http.Response) time.Duration {
+	h := resp.Header.Get("Retry-After")
+	if n, err := strconv.Atoi(h); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return time.Second
+}
+
+//wrap puts the limiter in front of d, base is the path of the base URL of the client
+func (l *limiter) wrap(d Doer, base string) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		b := l.bucket(endpointOf(base, req.URL.Path))
+		ctx := req.Context()
+		for attempt := 0; ; attempt++ {
+			err := b.wait(ctx)
+			if err != nil {
+				return nil, err
+			}
+			err = b.acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := d.Do(req)
+			if err != nil {
+				b.release()
+				return nil, err
+			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				b.pause(time.Now().Add(retryAfter(resp)))
+				rewindable := req.Body == nil || req.GetBody != nil
+				if attempt < b.cfg.Retries && rewindable {
+					resp.Body.Close()
+					b.release()
+					if req.GetBody != nil {
+						req.Body, err = req.GetBody()
+						if err != nil {
+							return nil, err
+						}
+					}
+					countRetry(ctx)
+					continue
+				}
+			}
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: b.release}
+			return resp, nil
+		}
+	})
+}
+
+type callInfoKey struct{}
+
+//callInfo collects what happens to a call inside the Doer chain
+type callInfo struct {
+	retries int
+	//synthetic is true when the response has been made by a DryRun
+	synthetic bool
+}
+
+func countRetry(ctx context.Context) {
+	if ci, ok := ctx.Value(callInfoKey{}).(*callInfo); ok {
+		ci.retries++
+	}
+}
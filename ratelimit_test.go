@@ -0,0 +1,136 @@
+package satisgo_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//tooMany answers 429 with Retry-After to the first n calls, the body of each call is kept in bodies
+func tooMany(n int32, retryAfter string, bodies *[]string) satisgo.Middleware {
+	var calls atomic.Int32
+	var mu sync.Mutex
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Body != nil {
+				b, _ := io.ReadAll(req.Body)
+				req.Body = io.NopCloser(strings.NewReader(string(b)))
+				mu.Lock()
+				*bodies = append(*bodies, string(b))
+				mu.Unlock()
+			}
+			if calls.Add(1) > n {
+				return next.Do(req)
+			}
+			h := make(http.Header)
+			h.Set("Retry-After", retryAfter)
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     h,
+				Body:       io.NopCloser(strings.NewReader("")),
+				Request:    req,
+			}, nil
+		})
+	}
+}
+
+func TestRateLimitBackOff(t *testing.T) {
+	var bodies []string
+	obs := new(recorder)
+	_, p, _ := newTestServer(t,
+		satisgo.WithObserver(obs),
+		satisgo.WithMiddleware(tooMany(1, "1", &bodies)),
+		satisgo.WithRateLimit(satisgo.EndpointUsers, satisgo.RateLimit{Retries: 1}),
+	)
+	start := time.Now()
+	_, err := p.UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 900*time.Millisecond {
+		t.Fatalf("the retry was sent after %s, want the second asked by Retry-After", d)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || !strings.Contains(bodies[1], testPhone) {
+		t.Fatalf("the body should be sent again as it was: %q", bodies)
+	}
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if len(obs.calls) != 1 || obs.calls[0].Retries != 1 || obs.calls[0].StatusCode != http.StatusOK {
+		t.Fatalf("unexpected calls %+v", obs.calls)
+	}
+}
+
+func TestRateLimitNoRetries(t *testing.T) {
+	var bodies []string
+	_, p, _ := newTestServer(t,
+		satisgo.WithMiddleware(tooMany(1, "0", &bodies)),
+		satisgo.WithRateLimit("", satisgo.RateLimit{}),
+	)
+	_, err := p.UserFromPhone(testPhone)
+	if err == nil || len(bodies) != 1 {
+		t.Fatalf("got %v after %d calls, want the 429 returned without retries", err, len(bodies))
+	}
+}
+
+func TestRateLimitRate(t *testing.T) {
+	_, p, _ := newTestServer(t, satisgo.WithRateLimit(satisgo.EndpointUsers, satisgo.RateLimit{Rate: 20, Burst: 1}))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := p.UserFromPhone(testPhone)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	//the first request uses the burst, the other two wait 50ms each
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("3 requests at 20/s took %s", d)
+	}
+	//the other endpoints have no limit
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		p.GetCharge("not-on-satispay")
+	}
+	if d := time.Since(start); d >= 90*time.Millisecond {
+		t.Fatalf("the charges endpoint should not be limited, took %s", d)
+	}
+}
+
+func TestRateLimitInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	slow := func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			n := current.Add(1)
+			defer current.Add(-1)
+			for {
+				m := peak.Load()
+				if n <= m || peak.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return next.Do(req)
+		})
+	}
+	_, p, _ := newTestServer(t,
+		satisgo.WithMiddleware(slow),
+		satisgo.WithRateLimit("", satisgo.RateLimit{MaxInFlight: 2}),
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.UserFromPhone(testPhone)
+		}()
+	}
+	wg.Wait()
+	if n := peak.Load(); n != 2 {
+		t.Fatalf("got %d requests in flight at once, want 2", n)
+	}
+}
@@ -0,0 +1,247 @@
+package satisgo
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	//KindCharge marks a ledger entry or a reconcile item as a charge
+	KindCharge = "charge"
+	//KindRefund marks a ledger entry or a reconcile item as a refund
+	KindRefund = "refund"
+)
+
+const (
+	//DiffMissing is an entry of the ledger that Satispay does not know about
+	DiffMissing = "MISSING"
+	//DiffExtra is an object recorded by Satispay that is not in the ledger
+	DiffExtra = "EXTRA"
+	//DiffAmount is an entry whose amount differs from the one recorded by Satispay
+	DiffAmount = "AMOUNT_MISMATCH"
+	//DiffStatus is a charge whose status differs from the one recorded by Satispay
+	DiffStatus = "STATUS_MISMATCH"
+)
+
+//LedgerEntry is a charge or a refund as it is recorded in the local books
+type LedgerEntry struct {
+	//Kind is KindCharge or KindRefund
+	Kind string `json:"kind"`
+	//ID is the Satispay id, when empty the entry is matched by metadata
+	ID string `json:"id,omitempty"`
+	//MetadataKey is the metadata key used to match the entry (ex. "order_id")
+	MetadataKey string `json:"metadata_key,omitempty"`
+	//MetadataValue is the value MetadataKey must have
+	MetadataValue string `json:"metadata_value,omitempty"`
+	//Amount is expressed in EuroCents
+	Amount uint64 `json:"amount"`
+	//Status is the expected status of a charge, empty means SUCCESS (ignored for refunds)
+	Status string `json:"status,omitempty"`
+}
+
+//reference is the human readable way to identify the entry in a report
+func (e *LedgerEntry) reference() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return e.MetadataKey + "=" + e.MetadataValue
+}
+
+//LedgerSource gives the local entries to reconcile for a period of time
+type LedgerSource interface {
+	LedgerEntries(ctx context.Context, from, to time.Time) ([]LedgerEntry, error)
+}
+
+//Ledger is the simplest LedgerSource: every entry is returned whatever the period is
+type Ledger []LedgerEntry
+
+//LedgerEntries implements LedgerSource
+func (l Ledger) LedgerEntries(ctx context.Context, from, to time.Time) ([]LedgerEntry, error) {
+	return l, nil
+}
+
+//ReconcileItem is a single difference between the ledger and Satispay
+type ReconcileItem struct {
+	Kind         string `json:"kind"`
+	Problem      string `json:"problem"`
+	ID           string `json:"id,omitempty"`
+	Reference    string `json:"reference,omitempty"`
+	LocalAmount  uint64 `json:"local_amount"`
+	RemoteAmount uint64 `json:"remote_amount"`
+	LocalStatus  string `json:"local_status,omitempty"`
+	RemoteStatus string `json:"remote_status,omitempty"`
+}
+
+//Reconciliation is the result of Reconcile
+type Reconciliation struct {
+	From  time.Time       `json:"from"`
+	To    time.Time       `json:"to"`
+	Items []ReconcileItem `json:"items"`
+	//Local are the totals computed from the ledger
+	Local Ammount `json:"local"`
+	//Remote are the totals given by the amounts endpoint
+	Remote Ammount `json:"remote"`
+}
+
+//Balanced is true when no difference has been found and the totals are the same
+func (r *Reconciliation) Balanced() bool {
+	return len(r.Items) == 0 &&
+		r.Local.TotalCharge == r.Remote.TotalCharge &&
+		r.Local.TotalRefund == r.Remote.TotalRefund
+}
+
+//WriteJSON exports the reconciliation as a JSON document
+func (r *Reconciliation) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+//WriteCSV exports the differences as CSV, one row for each item
+func (r *Reconciliation) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"kind", "problem", "id", "reference", "local_amount", "remote_amount", "local_status", "remote_status"})
+	if err != nil {
+		return err
+	}
+	for _, it := range r.Items {
+		err = cw.Write([]string{
+			it.Kind,
+			it.Problem,
+			it.ID,
+			it.Reference,
+			strconv.FormatUint(it.LocalAmount, 10),
+			strconv.FormatUint(it.RemoteAmount, 10),
+			it.LocalStatus,
+			it.RemoteStatus,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//Reconcile compares the entries of the ledger in [from, to) with what Satispay recorded
+//Entries are matched by ID, or by metadata when the ID is empty.
+//Charges are in the period when their charge_date is, refunds when their creation date is.
+func (p *Satis) Reconcile(ctx context.Context, from, to time.Time, source LedgerSource) (_ *Reconciliation, err error) {
+	c, span := p.WithContext(ctx).startSpan("Reconcile", attrTime(AttrFrom, from), attrTime(AttrTo, to))
+	defer span.end(&err)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	entries, err := source.LedgerEntries(c.context(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the ledger: %s", err.Error())
+	}
+	charges, err := c.allCharges()
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := c.allRefunds("")
+	if err != nil {
+		return nil, err
+	}
+	remote, err := c.getLongAmmount(from, to)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Reconciliation{
+		From:   from,
+		To:     to,
+		Items:  make([]ReconcileItem, 0),
+		Remote: *remote,
+	}
+	rec.Local.Currency = eur
+	inWindow := func(s string) bool {
+		t, ok := parseDate(s)
+		return ok && !t.Before(from) && t.Before(to)
+	}
+	matched := make(map[string]bool)
+	for _, e := range entries {
+		switch e.Kind {
+		case KindCharge:
+			status := e.Status
+			if status == "" {
+				status = Success
+			}
+			if status == Success {
+				rec.Local.TotalCharge += int(e.Amount)
+			}
+			ch := findCharge(charges, &e)
+			if ch == nil {
+				rec.Items = append(rec.Items, ReconcileItem{Kind: KindCharge, Problem: DiffMissing, ID: e.ID, Reference: e.reference(), LocalAmount: e.Amount, LocalStatus: status})
+				continue
+			}
+			matched[ch.ID] = true
+			if ch.Amount != e.Amount {
+				rec.Items = append(rec.Items, ReconcileItem{Kind: KindCharge, Problem: DiffAmount, ID: ch.ID, Reference: e.reference(), LocalAmount: e.Amount, RemoteAmount: ch.Amount, LocalStatus: status, RemoteStatus: ch.Status})
+			}
+			if ch.Status != status {
+				rec.Items = append(rec.Items, ReconcileItem{Kind: KindCharge, Problem: DiffStatus, ID: ch.ID, Reference: e.reference(), LocalAmount: e.Amount, RemoteAmount: ch.Amount, LocalStatus: status, RemoteStatus: ch.Status})
+			}
+		case KindRefund:
+			rec.Local.TotalRefund += int(e.Amount)
+			rf := findRefund(refunds, &e)
+			if rf == nil {
+				rec.Items = append(rec.Items, ReconcileItem{Kind: KindRefund, Problem: DiffMissing, ID: e.ID, Reference: e.reference(), LocalAmount: e.Amount})
+				continue
+			}
+			matched[rf.ID] = true
+			if rf.Amount != e.Amount {
+				rec.Items = append(rec.Items, ReconcileItem{Kind: KindRefund, Problem: DiffAmount, ID: rf.ID, Reference: e.reference(), LocalAmount: e.Amount, RemoteAmount: rf.Amount})
+			}
+		default:
+			return nil, fmt.Errorf("Ledger entry %s has an unknown kind %q", e.reference(), e.Kind)
+		}
+	}
+	for _, ch := range charges {
+		if !matched[ch.ID] && inWindow(ch.ChargeDate) {
+			rec.Items = append(rec.Items, ReconcileItem{Kind: KindCharge, Problem: DiffExtra, ID: ch.ID, RemoteAmount: ch.Amount, RemoteStatus: ch.Status})
+		}
+	}
+	for _, rf := range refunds {
+		if !matched[rf.ID] && inWindow(rf.Created) {
+			rec.Items = append(rec.Items, ReconcileItem{Kind: KindRefund, Problem: DiffExtra, ID: rf.ID, RemoteAmount: rf.Amount})
+		}
+	}
+	span.set(attrInt(AttrCount, int64(len(rec.Items))))
+	return rec, nil
+}
+
+func findCharge(list []Charge, e *LedgerEntry) *Charge {
+	for i := range list {
+		if e.ID != "" {
+			if list[i].ID == e.ID {
+				return &list[i]
+			}
+			continue
+		}
+		if v, ok := list[i].Metadata[e.MetadataKey]; ok && v == e.MetadataValue {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+func findRefund(list []Refund, e *LedgerEntry) *Refund {
+	for i := range list {
+		if e.ID != "" {
+			if list[i].ID == e.ID {
+				return &list[i]
+			}
+			continue
+		}
+		if v, ok := list[i].Metadata[e.MetadataKey]; ok && v == e.MetadataValue {
+			return &list[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package satisgo_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestReconcile(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	ok := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+	newTestCharge(t, srv, p, u, 700, satisgo.Success, map[string]string{"order_id": "B"})
+	extra := newTestCharge(t, srv, p, u, 300, satisgo.Success, nil)
+	r, err := p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: ok.ID, Amount: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := satisgo.Ledger{
+		{Kind: satisgo.KindCharge, ID: ok.ID, Amount: 500},
+		{Kind: satisgo.KindCharge, MetadataKey: "order_id", MetadataValue: "B", Amount: 999},
+		{Kind: satisgo.KindCharge, ID: "missing-charge", Amount: 100},
+		{Kind: satisgo.KindRefund, ID: r.ID, Amount: 200},
+	}
+	from, to := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	rec, err := p.Reconcile(context.Background(), from, to, ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Balanced() {
+		t.Fatal("reconciliation should not be balanced")
+	}
+	want := map[string]string{
+		satisgo.DiffAmount:  "order_id=B",
+		satisgo.DiffMissing: "missing-charge",
+		satisgo.DiffExtra:   extra.ID,
+	}
+	if len(rec.Items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(rec.Items), len(want), rec.Items)
+	}
+	for _, it := range rec.Items {
+		ref := it.Reference
+		if it.Problem == satisgo.DiffExtra {
+			ref = it.ID
+		}
+		if want[it.Problem] != ref {
+			t.Errorf("unexpected item %+v", it)
+		}
+	}
+	if rec.Local.TotalCharge != 1599 || rec.Remote.TotalCharge != 1500 || rec.Remote.TotalRefund != 200 {
+		t.Fatalf("unexpected totals local %+v remote %+v", rec.Local, rec.Remote)
+	}
+	var buf bytes.Buffer
+	err = rec.WriteCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Fatalf("got %d CSV lines, want 4", n)
+	}
+}
+
+func TestReconcileBalanced(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+	ledger := satisgo.Ledger{{Kind: satisgo.KindCharge, ID: c.ID, Amount: 500}}
+	rec, err := p.Reconcile(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour), ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Balanced() {
+		t.Fatalf("reconciliation should be balanced: %+v", rec)
+	}
+}
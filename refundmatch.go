@@ -0,0 +1,257 @@
+package satisgo
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Outcome of a charge in a RefundReport
+const (
+	RefundDone    = "REFUNDED"
+	RefundDryRun  = "WOULD_REFUND"
+	RefundSkipped = "SKIPPED"
+	RefundFailed  = "FAILED"
+)
+
+//ChargeFilter selects the charges to refund, the empty fields do not filter
+type ChargeFilter struct {
+	//IDs are the charges to look at (ex. read from a file with ReadChargeIDs), all the charges when empty
+	IDs []string
+	//MetadataKey and MetadataValue select the charges tagged with the value (ex. "event_id")
+	MetadataKey   string
+	MetadataValue string
+	//From and To limit the charge_date to [From, To)
+	From time.Time
+	To   time.Time
+	//Status of the charges, SUCCESS when empty
+	Status string
+}
+
+func (f *ChargeFilter) match(c *Charge) bool {
+	status := f.Status
+	if status == "" {
+		status = Success
+	}
+	if c.Status != status {
+		return false
+	}
+	if f.MetadataKey != "" && c.Metadata[f.MetadataKey] != f.MetadataValue {
+		return false
+	}
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+	t, ok := parseDate(c.ChargeDate)
+	if !ok {
+		return false
+	}
+	return (f.From.IsZero() || !t.Before(f.From)) && (f.To.IsZero() || t.Before(f.To))
+}
+
+//RefundOptions configures RefundMatching
+type RefundOptions struct {
+	//DryRun computes the report without creating any refund
+	DryRun bool
+	//Description and Metadata are given to every refund
+	Description string
+	Metadata    map[string]string
+}
+
+//RefundReportItem is what happened to a selected charge
+type RefundReportItem struct {
+	ChargeID string `json:"charge_id"`
+	//Outcome is RefundDone, RefundDryRun, RefundSkipped or RefundFailed
+	Outcome string `json:"outcome"`
+	//Amount is the ammount in EuroCents refunded, or to refund in a dry run
+	Amount   uint64 `json:"amount"`
+	RefundID string `json:"refund_id,omitempty"`
+	//Note tells why the charge has been skipped or the refund has failed
+	Note string `json:"note,omitempty"`
+}
+
+//RefundReport is the result of RefundMatching
+type RefundReport struct {
+	DryRun bool               `json:"dry_run"`
+	Reason string             `json:"reason"`
+	Items  []RefundReportItem `json:"items"`
+	//Total is the ammount in EuroCents refunded, or to refund in a dry run
+	Total uint64 `json:"total"`
+}
+
+//Failed returns the items whose refund has failed
+func (r *RefundReport) Failed() []RefundReportItem {
+	var list []RefundReportItem
+	for _, it := range r.Items {
+		if it.Outcome == RefundFailed {
+			list = append(list, it)
+		}
+	}
+	return list
+}
+
+//WriteJSON exports the report as a JSON document
+func (r *RefundReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+//WriteCSV exports the report as CSV, one row for each charge
+func (r *RefundReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"charge_id", "outcome", "amount", "refund_id", "note"})
+	if err != nil {
+		return err
+	}
+	for _, it := range r.Items {
+		err = cw.Write([]string{it.ChargeID, it.Outcome, strconv.FormatUint(it.Amount, 10), it.RefundID, it.Note})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//ReadChargeIDs reads the charge ids of a file, one for each line or in the first column of a CSV.
+//Empty lines and a header ("id" or "charge_id") are skipped
+func ReadChargeIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		id := strings.TrimSpace(strings.SplitN(s.Text(), ",", 2)[0])
+		id = strings.Trim(id, `"`)
+		if id == "" || id == "id" || id == "charge_id" {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, s.Err()
+}
+
+//RefundMatching refunds what is still refundable of every charge selected by filter, with reason
+//(ReasonCustomerRequest when empty). The refunds already made are taken into account, so running it
+//again only refunds what is left. An error is returned when the charges cannot be listed or a refund has failed,
+//the report tells which ones. The filter.IDs that cannot be read are RefundFailed items at the top of the report
+func (p *Satis) RefundMatching(ctx context.Context, filter ChargeFilter, reason string, opts RefundOptions) (_ *RefundReport, err error) {
+	p, span := p.WithContext(ctx).startSpan("RefundMatching")
+	defer span.end(&err)
+	if reason == "" {
+		reason = ReasonCustomerRequest
+	}
+	if err := new(Refund).SetReason(reason); err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(opts.Metadata); err != nil {
+		return nil, err
+	}
+	charges, unread, err := p.selectCharges(&filter)
+	if err != nil {
+		return nil, err
+	}
+	report := &RefundReport{DryRun: opts.DryRun, Reason: reason, Items: make([]RefundReportItem, 0, len(unread)+len(charges))}
+	report.Items = append(report.Items, unread...)
+	failed := len(unread)
+	for i := range charges {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		it := p.refundCharge(&charges[i], reason, &opts)
+		if it.Outcome == RefundFailed {
+			failed++
+		}
+		if it.Outcome == RefundDone || it.Outcome == RefundDryRun {
+			report.Total += it.Amount
+		}
+		report.Items = append(report.Items, it)
+	}
+	span.set(attrInt(AttrCount, int64(len(report.Items))), attrInt(AttrAmount, int64(report.Total)))
+	if failed > 0 {
+		return report, fmt.Errorf("%d of %d refunds have failed", failed, len(report.Items))
+	}
+	return report, nil
+}
+
+//selectCharges lists the charges of the filter, with the fewest calls possible
+//The ids of f.IDs that cannot be read are returned as RefundFailed items, they do not stop the others
+func (p *Satis) selectCharges(f *ChargeFilter) ([]Charge, []RefundReportItem, error) {
+	var list []Charge
+	var unread []RefundReportItem
+	switch {
+	case len(f.IDs) > 0:
+		for _, id := range f.IDs {
+			if err := p.context().Err(); err != nil {
+				return nil, nil, err
+			}
+			c, err := p.Charges.Get(id)
+			if err != nil {
+				unread = append(unread, RefundReportItem{ChargeID: id, Outcome: RefundFailed, Note: err.Error()})
+				continue
+			}
+			list = append(list, *c)
+		}
+	default:
+		//the charges of a metadata value are scanned as well: an index can miss some of them
+		all, err := p.allCharges()
+		if err != nil {
+			return nil, nil, err
+		}
+		list = all
+	}
+	selected := list[:0]
+	for _, c := range list {
+		if f.match(&c) {
+			selected = append(selected, c)
+		}
+	}
+	return selected, unread, nil
+}
+
+func (p *Satis) refundCharge(c *Charge, reason string, opts *RefundOptions) RefundReportItem {
+	it := RefundReportItem{ChargeID: c.ID}
+	refunds, err := p.Refunds.List(&RefundListParams{ChargeID: c.ID})
+	if err != nil {
+		it.Outcome = RefundFailed
+		it.Note = err.Error()
+		return it
+	}
+	var refunded uint64
+	for _, r := range refunds {
+		refunded += r.Amount
+	}
+	if refunded < c.Refund {
+		refunded = c.Refund
+	}
+	if refunded >= c.Amount {
+		it.Outcome = RefundSkipped
+		it.Note = "already refunded"
+		return it
+	}
+	it.Amount = c.Amount - refunded
+	if opts.DryRun {
+		it.Outcome = RefundDryRun
+		return it
+	}
+	r := &Refund{ChargeID: c.ID, Amount: it.Amount, Reason: reason, Description: opts.Description}
+	for k, v := range opts.Metadata {
+		r.SetMetadata(k, v)
+	}
+	//the key changes with what has been refunded: the same refund is never sent twice
+	key := fmt.Sprintf("refund-%s-%d", c.ID, refunded)
+	err = p.WithContext(ContextWithIdempotencyKey(p.context(), key)).Refunds.create(r)
+	if err != nil {
+		it.Outcome = RefundFailed
+		it.Note = err.Error()
+		return it
+	}
+	it.Outcome = RefundDone
+	it.RefundID = r.ID
+	return it
+}
@@ -0,0 +1,245 @@
+package satisgo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func refunded(t *testing.T, p *satisgo.Satis, chargeID string) uint64 {
+	t.Helper()
+	list, err := p.Refunds.List(&satisgo.RefundListParams{ChargeID: chargeID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total uint64
+	for _, r := range list {
+		total += r.Amount
+	}
+	return total
+}
+
+func TestRefundMatching(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	a := newTestCharge(t, srv, p, u, 500, satisgo.Success, map[string]string{"event_id": "A"})
+	partial := newTestCharge(t, srv, p, u, 700, satisgo.Success, map[string]string{"event_id": "A"})
+	other := newTestCharge(t, srv, p, u, 300, satisgo.Success, map[string]string{"event_id": "B"})
+	newTestCharge(t, srv, p, u, 900, satisgo.Required, map[string]string{"event_id": "A"})
+	_, err := p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: partial.ID, Amount: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := satisgo.ChargeFilter{MetadataKey: "event_id", MetadataValue: "A"}
+
+	report, err := p.RefundMatching(context.Background(), filter, "", satisgo.RefundOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Total != 500+500 || len(report.Items) != 2 {
+		t.Fatalf("unexpected dry run report %+v", report)
+	}
+	for _, it := range report.Items {
+		if it.Outcome != satisgo.RefundDryRun {
+			t.Fatalf("unexpected item %+v", it)
+		}
+	}
+	if refunded(t, p, a.ID) != 0 || refunded(t, p, partial.ID) != 200 {
+		t.Fatal("a dry run should not refund anything")
+	}
+
+	report, err = p.RefundMatching(context.Background(), filter, satisgo.ReasonDuplicate, satisgo.RefundOptions{
+		Metadata: map[string]string{"batch": "event-A"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 1000 || report.Reason != satisgo.ReasonDuplicate {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if refunded(t, p, a.ID) != 500 || refunded(t, p, partial.ID) != 700 || refunded(t, p, other.ID) != 0 {
+		t.Fatal("only what is left of the charges of event A should be refunded")
+	}
+
+	//the charges are refunded already
+	report, err = p.RefundMatching(context.Background(), filter, "", satisgo.RefundOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range report.Items {
+		if it.Outcome != satisgo.RefundSkipped {
+			t.Fatalf("unexpected item %+v", it)
+		}
+	}
+	if report.Total != 0 {
+		t.Fatalf("got total %d, want 0", report.Total)
+	}
+}
+
+func TestRefundMatchingPartialIndex(t *testing.T) {
+	idx := satisgo.NewMemoryIndex()
+	srv, p, u := newTestServer(t, satisgo.WithChargeIndex(idx, "event_id"))
+	indexed := newTestCharge(t, srv, p, u, 500, satisgo.Success, map[string]string{"event_id": "A"})
+	//created by a client without the index
+	other, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := newTestCharge(t, srv, other, u, 700, satisgo.Success, map[string]string{"event_id": "A"})
+
+	report, err := p.RefundMatching(context.Background(), satisgo.ChargeFilter{MetadataKey: "event_id", MetadataValue: "A"}, "", satisgo.RefundOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 1200 || len(report.Items) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if refunded(t, p, indexed.ID) != 500 || refunded(t, p, missing.ID) != 700 {
+		t.Fatal("the charges missing from the index should be refunded as well")
+	}
+}
+
+func TestRefundMatchingDates(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+	dry := satisgo.RefundOptions{DryRun: true}
+	now := time.Now()
+	cases := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{now.Add(-time.Hour), now.Add(time.Hour), 1},
+		{now.Add(time.Hour), time.Time{}, 0},
+		{time.Time{}, now.Add(-time.Hour), 0},
+	}
+	for _, tc := range cases {
+		report, err := p.RefundMatching(context.Background(), satisgo.ChargeFilter{From: tc.from, To: tc.to}, "", dry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Items) != tc.want || (tc.want == 1 && report.Items[0].ChargeID != c.ID) {
+			t.Errorf("from %s to %s: got %+v, want %d items", tc.from, tc.to, report.Items, tc.want)
+		}
+	}
+}
+
+func TestRefundMatchingBadID(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+	report, err := p.RefundMatching(context.Background(), satisgo.ChargeFilter{IDs: []string{"not-on-satispay", c.ID}}, "", satisgo.RefundOptions{})
+	if err == nil {
+		t.Fatal("the id not found should be reported")
+	}
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].ChargeID != "not-on-satispay" || failed[0].Note == "" {
+		t.Fatalf("unexpected failed items %+v", failed)
+	}
+	if len(report.Items) != 2 || report.Items[1].Outcome != satisgo.RefundDone || refunded(t, p, c.ID) != 500 {
+		t.Fatalf("the other charges should be refunded: %+v", report.Items)
+	}
+}
+
+//failRefund refuses the first creation of a refund, before it reaches the API when lost is false
+//and after the API has created it when lost is true
+func failRefund(lost bool) satisgo.Middleware {
+	var done atomic.Bool
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/refunds") || done.Swap(true) {
+				return next.Do(req)
+			}
+			if lost {
+				resp, err := next.Do(req)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+			return nil, errors.New("connection reset")
+		})
+	}
+}
+
+func TestRefundMatchingRerun(t *testing.T) {
+	for _, lost := range []bool{false, true} {
+		srv, p, u := newTestServer(t, satisgo.WithMiddleware(failRefund(lost)))
+		c1 := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+		c2 := newTestCharge(t, srv, p, u, 700, satisgo.Success, nil)
+		filter := satisgo.ChargeFilter{IDs: []string{c1.ID, c2.ID}}
+		report, err := p.RefundMatching(context.Background(), filter, "", satisgo.RefundOptions{})
+		if err == nil || len(report.Failed()) != 1 || report.Failed()[0].ChargeID != c1.ID {
+			t.Fatalf("lost %v: the first refund should fail: %v %+v", lost, err, report)
+		}
+		_, err = p.RefundMatching(context.Background(), filter, "", satisgo.RefundOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refunded(t, p, c1.ID) != 500 || refunded(t, p, c2.ID) != 700 {
+			t.Fatalf("lost %v: every charge should be refunded once, got %d and %d", lost, refunded(t, p, c1.ID), refunded(t, p, c2.ID))
+		}
+	}
+}
+
+func TestRefundIdempotencyKey(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+	params := satisgo.RefundCreateParams{ChargeID: c.ID, Amount: 100, IdempotencyKey: "refund-1"}
+	r1, err := p.Refunds.Create(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := p.Refunds.Create(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.ID != r2.ID || refunded(t, p, c.ID) != 100 {
+		t.Fatalf("the same Idempotency-Key should give the same refund, got %s and %s", r1.ID, r2.ID)
+	}
+}
+
+func TestRefundReport(t *testing.T) {
+	report := &satisgo.RefundReport{
+		Reason: satisgo.ReasonCustomerRequest,
+		Items: []satisgo.RefundReportItem{
+			{ChargeID: "c1", Outcome: satisgo.RefundDone, Amount: 500, RefundID: "r1"},
+			{ChargeID: "c2", Outcome: satisgo.RefundFailed, Note: "boom, with a comma"},
+		},
+		Total: 500,
+	}
+	var buf bytes.Buffer
+	err := report.WriteCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "charge_id" || rows[1][2] != "500" || rows[2][4] != "boom, with a comma" {
+		t.Fatalf("unexpected CSV %q", rows)
+	}
+	buf.Reset()
+	err = report.WriteJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back satisgo.RefundReport
+	err = json.Unmarshal(buf.Bytes(), &back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Total != 500 || len(back.Items) != 2 || back.Items[0].RefundID != "r1" || back.Items[1].Outcome != satisgo.RefundFailed {
+		t.Fatalf("unexpected JSON report %+v", back)
+	}
+	ids, err := satisgo.ReadChargeIDs(strings.NewReader("charge_id,amount\nc1,500\n\n\"c2\",700\n"))
+	if err != nil || len(ids) != 2 || ids[0] != "c1" || ids[1] != "c2" {
+		t.Fatalf("got ids %q, %v", ids, err)
+	}
+}
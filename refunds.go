@@ -22,16 +22,21 @@ type Refund struct {
 	//Metadata has max 20 fields(key value storage for charges)
 	Metadata map[string]string `json:"metadata,omitempty"`
 	//Created is the time in UnixMilli of the creation of the refund
-	Created string `json:"reason,omitempty"`
+	Created string `json:"created,omitempty"`
 	//Reason is the reason a refund occurred
 	Reason string `json:"reason,omitempty"`
 }
 
 //NewRefund generates a refund based on the given charge
+//
+//Deprecated: use p.Refunds.Create
 func (c *Charge) NewRefund() (*Refund, error) {
 	if c.ID == "" {
 		return nil, fmt.Errorf("before creating a refund, you must create the charge thru the appropriate method")
 	}
+	if !c.CanRefund() {
+		return nil, &StateError{ChargeID: c.ID, Status: c.Status, Op: "refund"}
+	}
 	r := new(Refund)
 	r.ChargeID = c.ID
 	r.Currency = eur
@@ -39,10 +44,15 @@ func (c *Charge) NewRefund() (*Refund, error) {
 }
 
 //NewRefundWithAmount generates a refund based on the given charge and the ammount supplied
+//
+//Deprecated: use p.Refunds.Create
 func (c *Charge) NewRefundWithAmount(a float64) (*Refund, error) {
 	if c.ID == "" {
 		return nil, fmt.Errorf("before creating a refund, you must create the charge thru the appropriate method")
 	}
+	if !c.CanRefund() {
+		return nil, &StateError{ChargeID: c.ID, Status: c.Status, Op: "refund"}
+	}
 	r := new(Refund)
 	r.ChargeID = c.ID
 	r.Currency = eur
@@ -50,6 +60,9 @@ func (c *Charge) NewRefundWithAmount(a float64) (*Refund, error) {
 	if err != nil {
 		return nil, err
 	}
+	if r.Amount > c.Refundable() {
+		return nil, fmt.Errorf("ammount to refund is bigger than the refundable ammount of the charge (%d cents)", c.Refundable())
+	}
 	return r, nil
 }
 
@@ -99,34 +112,49 @@ func (r *Refund) SetAmmount(a float64) error {
 
 //SetMetadata is used to add metadata to a refund without making any mess around
 //THere are some limits: max 20pairs, key length max 45 chars, value max 500 chars
+//An empty value deletes the key
 //THIS IS NOT MANDATORY
 func (r *Refund) SetMetadata(key, value string) error {
-	if r.Metadata == nil {
-		r.Metadata = make(map[string]string)
-	}
-	if len(key) > 45 || len(key) < 2 {
-		return fmt.Errorf("The key has the wrong format (too long/short)")
-	}
-	if len(value) > 500 || len(value) < 2 {
-		return fmt.Errorf("The value has the wrong format (too long/short)")
-	}
-	_, exist := r.Metadata[key]
-	if len(r.Metadata) == 20 && !exist {
-		return fmt.Errorf("Metadata is too long already")
+	m, err := setMetadata(r.Metadata, key, value)
+	r.Metadata = m
+	return err
+}
+
+//SetMetadataStruct merges the fields of v tagged with `metadata:"key[,omitempty]"` into the metadata
+//ints, floats, bools, strings and encoding.TextMarshaler (time.Time, enums) are supported.
+//Nothing is changed if a limit would be exceeded
+func (r *Refund) SetMetadataStruct(v interface{}) error {
+	m, err := encodeMetadata(r.Metadata, v)
+	if err != nil {
+		return err
 	}
-	r.Metadata[key] = value
+	r.Metadata = m
 	return nil
 }
 
+//DecodeMetadata fills the fields of v tagged with `metadata:"key"` from the metadata of the refund
+func (r *Refund) DecodeMetadata(v interface{}) error {
+	return decodeMetadata(r.Metadata, v)
+}
+
 //GetRefund returns a refund provided a refund_id
+//
+//Deprecated: use p.Refunds.Get
 func (p *Satis) GetRefund(id string) (*Refund, error) {
+	return p.Refunds.Get(id)
+}
+
+//Get returns the refund with the given id
+func (s *RefundService) Get(id string) (_ *Refund, err error) {
+	p, span := s.p.startSpan("GetRefund", attrString(AttrRefundID, id))
+	defer span.end(&err)
 	r, err := http.NewRequest("GET", p.refundsURL()+"/"+id, nil)
 	if err != nil {
 		return nil, err
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return nil, fmt.Errorf("Error making the call to API: %s", err.Error())
+		return nil, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return nil, fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -136,46 +164,80 @@ func (p *Satis) GetRefund(id string) (*Refund, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
+	//a response replayed from another call would still be signed
+	if c.ID != id {
+		return nil, fmt.Errorf("Response is about refund %q instead of %q", c.ID, id)
+	}
+	span.set(refundAttrs(c)...)
 	return c, nil
 }
 
 //UpdateRefundMetadata returns a modified Refund provided one
+//
+//Deprecated: use p.Refunds.Update
 func (r *Refund) UpdateRefundMetadata(p *Satis) error {
 	if r.Metadata == nil {
 		return fmt.Errorf("metadata not initialized yet, nothing to update")
 	}
+	ref, err := p.Refunds.Update(r.ID, RefundUpdateParams{Metadata: r.Metadata})
+	if err != nil {
+		return err
+	}
+	*r = *ref
+	return nil
+}
+
+//Update changes the metadata of a refund
+func (s *RefundService) Update(id string, params RefundUpdateParams) (_ *Refund, err error) {
+	p, span := s.p.startSpan("UpdateRefundMetadata", attrString(AttrRefundID, id))
+	defer span.end(&err)
+	if params.Metadata == nil {
+		return nil, fmt.Errorf("nothing to update in refund %s", id)
+	}
+	if err := validateMetadata(params.Metadata); err != nil {
+		return nil, err
+	}
 	type body struct {
 		Metadata map[string]string `json:"metadata"`
 	}
 	var bod body
-	bod.Metadata = r.Metadata
+	bod.Metadata = params.Metadata
 	data, err := json.Marshal(&bod)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	input := bytes.NewReader(data)
-	req, err := http.NewRequest("PUT", p.refundsURL()+"/"+r.ID, input)
+	req, err := http.NewRequest("PUT", p.refundsURL()+"/"+id, input)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	status, b, err := p.makeCall(req)
 	if err != nil {
-		return fmt.Errorf("Error making the call to API: %s", err.Error())
+		return nil, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
-		return fmt.Errorf("Return status is %d:not compatible with the success case", status)
+		return nil, fmt.Errorf("Return status is %d:not compatible with the success case", status)
 	}
-	ch := new(Refund)
-	err = json.Unmarshal(b, ch)
+	r := new(Refund)
+	err = json.Unmarshal(b, r)
 	if err != nil {
-		return fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
+		return nil, fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
-	*r = *ch
-	return nil
+	span.set(refundAttrs(r)...)
+	return r, nil
 }
 
 //CreateRefund is the function that makes the call to Satispay API to request a refund with the given parameters
+//
+//Deprecated: use p.Refunds.Create
 func (r *Refund) CreateRefund(p *Satis) error {
+	return p.Refunds.create(r)
+}
+
+//create sends r to Satispay and fills it with the refund created
+func (s *RefundService) create(r *Refund) (err error) {
+	p, span := s.p.startSpan("CreateRefund", attrString(AttrChargeID, r.ChargeID), attrInt(AttrAmount, int64(r.Amount)))
+	defer span.end(&err)
 	if r.ChargeID == "" {
 		return fmt.Errorf("Charge ID cannot be empty")
 	}
@@ -186,8 +248,8 @@ func (r *Refund) CreateRefund(p *Satis) error {
 	if r.ID != "" {
 		return fmt.Errorf("Charge ID already exist: charge already created")
 	}
-	if len(r.Metadata) > 20 {
-		return fmt.Errorf("Metadata is too long")
+	if err := validateMetadata(r.Metadata); err != nil {
+		return err
 	}
 	//some more checking if Refund object is good
 	data, err := json.Marshal(r)
@@ -202,7 +264,7 @@ func (r *Refund) CreateRefund(p *Satis) error {
 	}
 	status, b, err := p.makeCall(req)
 	if err != nil {
-		return fmt.Errorf("Error making the call to API: %s", err.Error())
+		return fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -213,5 +275,9 @@ func (r *Refund) CreateRefund(p *Satis) error {
 		return fmt.Errorf("Error unmarshaling response to Charge: %s", err.Error())
 	}
 	*r = *ref
+	span.set(refundAttrs(r)...)
+	if p.observer != nil {
+		p.observer.ObserveRefund(*r)
+	}
 	return nil
 }
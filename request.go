@@ -1,33 +1,15 @@
 package satisgo
 
 import (
-	"crypto/sha512"
-	"crypto/tls"
-	"encoding/base64"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
-	"strconv"
 	"time"
-
-	"github.com/buger/jsonparser"
-	"github.com/fatih/color"
 )
 
-func (p *Satis) makeCall(req *http.Request) (int, []byte, error) {
-	var insecure *tls.Config
-	if p.env == dev {
-		insecure = &tls.Config{
-			MinVersion:         tls.VersionTLS12,
-			InsecureSkipVerify: true,
-		}
-	} else {
-		insecure = &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		}
-	}
-	insecure.BuildNameToCertificate()
+//newClient builds the HTTP client shared by all the calls of p
+func (p *Satis) newClient() *http.Client {
 	tr := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -38,119 +20,79 @@ func (p *Satis) makeCall(req *http.Request) (int, []byte, error) {
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig:       insecure,
+		TLSClientConfig:       p.tlsConfig(),
 	}
-	client := &http.Client{
+	return &http.Client{
 		Transport: tr,
 		Timeout:   3 * time.Second,
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.bearer))
-	if req.Method == http.MethodPost {
-		req.Header.Set("Idempotency-Key", generateUUID())
+}
+
+//newDoer puts the middlewares, the rate limiter and the circuit breaker in front of the client
+//In dry run mode the mutating calls stop right before the client
+func (p *Satis) newDoer(client *http.Client) Doer {
+	var base Doer = client
+	if p.dryRun != nil {
+		base = p.dryRun.wrap(client, p.integrity, p.basePath())
 	}
-	resp, err := client.Do(req)
-	if err != nil {
-		panic(err)
+	d := p.chain(base)
+	if p.limiter != nil {
+		d = p.limiter.wrap(d, p.basePath())
 	}
-	defer resp.Body.Close()
-	body, err := checkIntegrity(resp)
-	if err != nil {
-		return -1, nil, err
+	if p.breaker != nil {
+		d = p.breaker.wrap(d, p.basePath())
 	}
+	return d
+}
 
-	// this block needs to be taken out ------------------------
-	err = handleHeader(resp.StatusCode)
-	if err != nil {
-		//Parse body to find error code and message
-		code, e := jsonparser.GetInt(body, "code")
-		if e != nil {
-			return -1, nil, err
-		}
-		msg, e := jsonparser.GetString(body, "message")
-		if e != nil {
-			return -1, nil, err
-		}
-		return -1, nil, fmt.Errorf("%s --> CODE %d: %s", err.Error(), code, msg)
-	}
-	// till here -------------------------------------------------
-	return resp.StatusCode, body, nil
+type idempotencyKeyKey struct{}
+
+//ContextWithIdempotencyKey makes the POST requests of a client bound to ctx (see WithContext) send key
+//as Idempotency-Key instead of a random one: sending again a creation with the same key does not create twice
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
 }
 
-func checkIntegrity(r *http.Response) ([]byte, error) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
-	color.Yellow(string(body))
-	color.Blue(fmt.Sprint(r.Header))
-	//checkin content lenght
-	lenght, ok := r.Header["Content-Length"]
-	if ok != true {
-		return nil, fmt.Errorf("Content-Lenght value in header does not exist")
-	}
-	if len(lenght) != 1 {
-		return nil, fmt.Errorf("Multiple Content-Lenght values in header")
-	}
-	l, err := strconv.Atoi(lenght[0])
-	if err != nil {
-		return nil, err
-	}
-	if len(body) != l {
-		return nil, fmt.Errorf("Content-Lenght value in header is not true")
-	}
-	if r.StatusCode == 204 {
-		return []byte(""), nil
-	}
-	//checking content type
-	t, ok := r.Header["Content-Type"]
-	if ok != true {
-		return nil, fmt.Errorf("Content-Type value in header does not exist")
-	}
-	if len(t) != 1 {
-		return nil, fmt.Errorf("Multiple Content-Type values in header")
-	}
-	if t[0] != "application/json" && t[0] != "application/json;charset=utf-8" {
-		return nil, fmt.Errorf("Content-Type value in header is not correct")
-	}
-	//check digest
-	digest, ok := r.Header["Digest"]
-	if ok != true {
-		return nil, fmt.Errorf("Content-Type value in header does not exist")
-	}
-	if len(digest) != 1 {
-		return nil, fmt.Errorf("Multiple Content-Type values in header")
+func idempotencyKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+func (p *Satis) makeCall(req *http.Request) (_ int, _ []byte, err error) {
+	p, span := p.startSpan("call", attrString(AttrMethod, req.Method), attrString(AttrEndpoint, endpointOf(p.basePath(), req.URL.Path)))
+	defer span.end(&err)
+	info := new(callInfo)
+	req = req.WithContext(context.WithValue(p.context(), callInfoKey{}, info))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.bearer))
+	if req.Method == http.MethodPost {
+		key, ok := idempotencyKey(req.Context())
+		if !ok {
+			key = generateUUID()
+		}
+		req.Header.Set("Idempotency-Key", key)
 	}
-	hash := sha512.New()
-	_, err = hash.Write(body)
+	start := time.Now()
+	resp, err := p.doer.Do(req)
+	span.set(attrInt(AttrRetries, int64(info.retries)))
 	if err != nil {
-		return nil, err
-	}
-	dig := "SHA-512=" + base64.StdEncoding.EncodeToString(hash.Sum(nil))
-	if digest[0] != dig {
-		return nil, fmt.Errorf("Digest is incorrect")
-	}
-	//check wlt
-	wlt, ok := r.Header["X-Satispay-Cid"]
-	if ok != true {
-		return nil, fmt.Errorf("X-Satispay-Cid value in header does not exist")
+		p.observeCall(req, start, 0, info.retries, false, err)
+		return -1, nil, err
 	}
-	if len(wlt) != 1 {
-		return nil, fmt.Errorf("Multiple X-Satispay-Cid values in header")
+	defer resp.Body.Close()
+	span.set(attrInt(AttrStatusCode, int64(resp.StatusCode)))
+	body, err := checkIntegrity(resp, p.integrity)
+	if err == nil && p.signatures != nil && !info.synthetic {
+		err = p.signatures.verify(resp.Header, "", body)
 	}
-	w, err := jsonparser.GetString(body, "wlt")
 	if err != nil {
-		if err.Error() != "Key path not found" {
-			return nil, fmt.Errorf("Error Parsing WLT from body: %s", err.Error())
-		}
+		p.observeCall(req, start, resp.StatusCode, info.retries, true, err)
+		return -1, nil, err
 	}
-	if err == nil {
-		if w != wlt[0] {
-			return nil, fmt.Errorf("WLT checks has gone wrong")
-		}
+	p.observeCall(req, start, resp.StatusCode, info.retries, false, nil)
+
+	if handleHeader(resp.StatusCode) != nil {
+		return -1, nil, newAPIError(resp.StatusCode, body)
 	}
-	//Still NOT checking
-	//   - SERVER header
-	//   - DATE HEADER
-	return body, nil
+	return resp.StatusCode, body, nil
 }
@@ -0,0 +1,109 @@
+package satisgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+//Status of the phone numbers given to ResolveUsers
+const (
+	ResolveFound    = "found"
+	ResolveNotFound = "not_found"
+	ResolveInvalid  = "invalid"
+	ResolveError    = "error"
+)
+
+//ResolveOptions configures ResolveUsers, zero values take the defaults
+type ResolveOptions struct {
+	//Workers is the number of lookups running at the same time (8)
+	Workers int
+	//Rate is the number of lookups started per second (0 means no limit)
+	Rate float64
+	//OnResult is called as soon as a phone number is resolved (ex. to show the progress), it must be safe for concurrent use
+	OnResult func(ResolveResult)
+}
+
+//ResolveResult is the outcome of the lookup of a phone number
+type ResolveResult struct {
+	//Index is the position of the phone number in the list given to ResolveUsers
+	Index int
+	Phone string
+	//Normalized is the E.164 number, empty when it is invalid
+	Normalized string
+	//Status is ResolveFound, ResolveNotFound, ResolveInvalid or ResolveError
+	Status string
+	//User is set when the Status is ResolveFound
+	User *User
+	//Err is set when the Status is ResolveInvalid or ResolveError
+	Err error
+}
+
+//ResolveUsers looks up the Satispay users of the phone numbers with a pool of workers.
+//The results are in the same order as phones. When ctx is done the numbers left get a ResolveError
+//with the error of the context, which is also returned. Use WithUserCache to skip the repeated numbers
+func (p *Satis) ResolveUsers(ctx context.Context, phones []string, opts ResolveOptions) ([]ResolveResult, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	var b *bucket
+	if opts.Rate > 0 {
+		b = &bucket{cfg: RateLimit{Rate: opts.Rate, Burst: 1}, tokens: 1, last: time.Now()}
+	}
+	c := p.WithContext(ctx)
+	results := make([]ResolveResult, len(phones))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = c.resolveUser(ctx, b, i, phones[i])
+				if opts.OnResult != nil {
+					opts.OnResult(results[i])
+				}
+			}
+		}()
+	}
+	for i := range phones {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+func (p *Satis) resolveUser(ctx context.Context, b *bucket, i int, phone string) ResolveResult {
+	r := ResolveResult{Index: i, Phone: phone}
+	n, err := NormalizePhone(phone, p.region)
+	if err != nil {
+		r.Status = ResolveInvalid
+		r.Err = err
+		return r
+	}
+	r.Normalized = n
+	if b != nil {
+		err = b.wait(ctx)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		r.User, err = p.Users.Lookup(n)
+	}
+	switch {
+	case err == nil:
+		r.Status = ResolveFound
+	case IsNotFound(err):
+		r.Status = ResolveNotFound
+	case errors.Is(err, ErrInvalidPhone):
+		r.Status = ResolveInvalid
+		r.Err = err
+	default:
+		r.Status = ResolveError
+		r.Err = err
+	}
+	return r
+}
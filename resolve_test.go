@@ -0,0 +1,88 @@
+package satisgo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestResolveUsers(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	other := srv.AddUser("+393332222222")
+	phones := []string{"333 123 4567", "+393339999999", "not a phone", "+39 333 222 2222", testPhone}
+	var seen atomic.Int32
+	results, err := p.ResolveUsers(context.Background(), phones, satisgo.ResolveOptions{
+		Workers:  3,
+		OnResult: func(satisgo.ResolveResult) { seen.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		status, id string
+	}{
+		{satisgo.ResolveFound, u.ID},
+		{satisgo.ResolveNotFound, ""},
+		{satisgo.ResolveInvalid, ""},
+		{satisgo.ResolveFound, other.ID},
+		{satisgo.ResolveFound, u.ID},
+	}
+	for i, r := range results {
+		if r.Index != i || r.Phone != phones[i] || r.Status != want[i].status {
+			t.Errorf("result %d: got %+v, want %s", i, r, want[i].status)
+			continue
+		}
+		if want[i].id != "" && (r.User == nil || r.User.ID != want[i].id) {
+			t.Errorf("result %d: got user %+v, want %s", i, r.User, want[i].id)
+		}
+		if r.Status == satisgo.ResolveInvalid && !errors.Is(r.Err, satisgo.ErrInvalidPhone) {
+			t.Errorf("result %d: got error %v, want ErrInvalidPhone", i, r.Err)
+		}
+	}
+	if n := seen.Load(); n != int32(len(phones)) {
+		t.Fatalf("OnResult called %d times, want %d", n, len(phones))
+	}
+}
+
+func TestResolveUsersCanceled(t *testing.T) {
+	_, p, _ := newTestServer(t)
+	phones := make([]string, 20)
+	for i := range phones {
+		phones[i] = fmt.Sprintf("+3933300000%02d", i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var done atomic.Int32
+	results, err := p.ResolveUsers(ctx, phones, satisgo.ResolveOptions{
+		Workers: 1,
+		Rate:    100,
+		OnResult: func(satisgo.ResolveResult) {
+			if done.Add(1) == 3 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want the error of the context", err)
+	}
+	last := results[len(results)-1]
+	if last.Status != satisgo.ResolveError || !errors.Is(last.Err, context.Canceled) {
+		t.Fatalf("the numbers left should get the error of the context, got %+v", last)
+	}
+}
+
+func TestResolveUsersRate(t *testing.T) {
+	_, p, _ := newTestServer(t)
+	start := time.Now()
+	_, err := p.ResolveUsers(context.Background(), []string{testPhone, testPhone, testPhone}, satisgo.ResolveOptions{Rate: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("3 lookups at 20/s took %s", d)
+	}
+}
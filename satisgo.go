@@ -1,19 +1,52 @@
 package satisgo
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 )
 
 //Satis is the base unit for a payment/action with the satispay API
+//It is safe for concurrent use by multiple goroutines: the configuration is set once by New,
+//the HTTP transport is shared by all the calls and the copies made by WithContext
 type Satis struct {
+	//Users, Charges, Refunds and Amounts group the operations of the API: p.Charges.Create(params)
+	Users   *UserService
+	Charges *ChargeService
+	Refunds *RefundService
+	Amounts *AmountService
+
 	bearer   string
 	env      string
-	verified bool
+	baseURL  string
+	verified *int32
+	ctx      context.Context
+	index    *chargeIndexer
+	onEvent  func(ChargeEvent)
+	observer Observer
+	tracer   Tracer
+	breaker  *breaker
+	limiter  *limiter
+
+	middlewares []Middleware
+	integrity   *IntegrityPolicy
+	signatures  *SignatureVerifier
+	tls         *tls.Config
+	pins        []string
+	region      string
+	users       *userCache
+	dryRun      *DryRun
+	client      *http.Client
+	doer        Doer
 }
 
+//Option is used to configure the client when it is generated with New
+type Option func(*Satis) error
+
 //New is the generator for a basic interaction with the API
-func New(bearer, env string) (*Satis, error) {
+func New(bearer, env string, opts ...Option) (*Satis, error) {
 	p := new(Satis)
 	switch env {
 	case "staging":
@@ -28,11 +61,52 @@ func New(bearer, env string) (*Satis, error) {
 	//find some parameters to check the string-validity of bearer
 	//mybe only allow a subset of characters
 	p.bearer = bearer
+	p.verified = new(int32)
+	for _, opt := range opts {
+		err := opt(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.integrity == nil {
+		pol := DefaultIntegrityPolicy
+		if p.signatures != nil {
+			//the signed Date is what keeps an old signed response from being replayed
+			pol.Date = IntegrityRequire
+		}
+		p.integrity = &pol
+	}
+	p.client = p.newClient()
+	p.doer = p.newDoer(p.client)
+	p.bindServices()
 	return p, nil
 }
 
+//WithContext returns a shallow copy of p whose calls to the API are bound to ctx
+//Use it to cancel long listings or to set deadlines: p.WithContext(ctx).Charges.List(nil)
+func (p *Satis) WithContext(ctx context.Context) *Satis {
+	if ctx == nil {
+		panic("nil context")
+	}
+	p2 := new(Satis)
+	*p2 = *p
+	p2.ctx = ctx
+	p2.bindServices()
+	return p2
+}
+
+//context returns the context the calls are bound to
+func (p *Satis) context() context.Context {
+	if p.ctx != nil {
+		return p.ctx
+	}
+	return context.Background()
+}
+
 //Verify is used to make sure the token is correct
-func (p *Satis) Verify() error {
+func (p *Satis) Verify() (err error) {
+	p, span := p.startSpan("Verify")
+	defer span.end(&err)
 	r, err := http.NewRequest("GET", p.verificationURL(), nil)
 	if err != nil {
 		return err
@@ -44,5 +118,11 @@ func (p *Satis) Verify() error {
 	if status != 204 {
 		return fmt.Errorf("Return status is %d:not compatible with the verification", status)
 	}
+	atomic.StoreInt32(p.verified, 1)
 	return nil
 }
+
+//Verified is true once Verify has succeeded
+func (p *Satis) Verified() bool {
+	return atomic.LoadInt32(p.verified) == 1
+}
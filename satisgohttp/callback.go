@@ -0,0 +1,49 @@
+package satisgohttp
+
+import (
+	"net/http"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//CallbackParam is the query parameter carrying the charge id in the callback_url
+//Use it in the URL given to SetCallbackURL: https://example.com/callback?charge_id={uuid}
+const CallbackParam = "charge_id"
+
+//Callback receives the notifications Satispay sends when a charge changes status
+//The notification only carries the id, so the charge is fetched from the API before calling OnCharge
+type Callback struct {
+	Client satisgo.Client
+	//OnCharge is called with the up to date charge, an error makes the handler answer 500
+	OnCharge func(r *http.Request, c *satisgo.Charge) error
+	//Verifier, when set, rejects with 401 the notifications without a valid Satispay signature
+	Verifier *satisgo.SignatureVerifier
+}
+
+//Handle processes the notification of the request and returns the HTTP status to answer with
+//It is the core of ServeHTTP, exposed for the framework adapters
+func (cb *Callback) Handle(r *http.Request) int {
+	if cb.Verifier != nil && cb.Verifier.VerifyRequest(r) != nil {
+		return http.StatusUnauthorized
+	}
+	id := r.URL.Query().Get(CallbackParam)
+	if id == "" {
+		return http.StatusBadRequest
+	}
+	c, err := cb.Client.BindContext(r.Context()).ChargeAPI().Get(id)
+	if err != nil {
+		return http.StatusBadGateway
+	}
+	if cb.OnCharge != nil {
+		err = cb.OnCharge(r, c)
+		if err != nil {
+			return http.StatusInternalServerError
+		}
+	}
+	return http.StatusNoContent
+}
+
+//ServeHTTP implements http.Handler
+func (cb *Callback) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(cb.Handle(r))
+}
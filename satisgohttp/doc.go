@@ -0,0 +1,11 @@
+/*
+Package satisgohttp gates net/http routes behind a paid Satispay charge.
+
+The Middleware creates a charge for the user resolved from the request, keeps it in a Session
+and answers with a pending payment response until GetCharge reports it as SUCCESS.
+The charge of the session opens the gate only if it matches the user, ammount and metadata of the request,
+and only once: the next request needs a new payment. CookieSession signs the charge id it keeps.
+CallbackHandler receives the notifications Satispay sends to the callback_url of a charge.
+
+*/
+package satisgohttp
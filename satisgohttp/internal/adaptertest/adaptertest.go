@@ -0,0 +1,98 @@
+/*
+Package adaptertest runs the same tests on every framework adapter of satisgohttp.
+
+*/
+package adaptertest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgohttp"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+//Routes builds the handler of an adapter: GET /paid behind the middleware configured by cfg,
+//answering with the id of the paid charge, and GET /callback served by cb
+type Routes func(t *testing.T, cfg satisgohttp.Config, cb *satisgohttp.Callback) http.Handler
+
+func setup(t *testing.T, routes Routes) (*satisgotest.Server, http.Handler) {
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	u := srv.AddUser("+393331234567")
+	p, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := satisgohttp.Config{
+		Client: p,
+		Name:   "paid",
+		User: func(r *http.Request) (*satisgo.User, error) {
+			return &u, nil
+		},
+		Charge: func(r *http.Request, c *satisgo.Charge) error {
+			return c.SetAmmount(1.5)
+		},
+		CallbackURL: "http://example.com/callback?charge_id={uuid}",
+		Session:     &satisgohttp.CookieSession{Key: []byte(strings.Repeat("k", 32))},
+	}
+	return srv, routes(t, cfg, &satisgohttp.Callback{Client: p})
+}
+
+func serve(h http.Handler, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+//Run tests the middleware and the callback handler of an adapter
+func Run(t *testing.T, routes Routes) {
+	t.Run("Middleware", func(t *testing.T) {
+		srv, h := setup(t, routes)
+		w := serve(h, "/paid")
+		if w.Code != http.StatusPaymentRequired {
+			t.Fatalf("first request: got %d, want %d", w.Code, http.StatusPaymentRequired)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("expected the pending charge cookie, got %v", cookies)
+		}
+		id := srv.Charges()[0].ID
+
+		w = serve(h, "/paid", cookies[0])
+		if w.Code != http.StatusPaymentRequired {
+			t.Fatalf("pending request: got %d, want %d", w.Code, http.StatusPaymentRequired)
+		}
+		if n := len(srv.Charges()); n != 1 {
+			t.Fatalf("a pending request must not create a new charge, got %d charges", n)
+		}
+
+		srv.SetChargeStatus(id, satisgo.Success, "")
+		w = serve(h, "/paid", cookies[0])
+		if w.Code != http.StatusOK {
+			t.Fatalf("paid request: got %d, want %d", w.Code, http.StatusOK)
+		}
+		if w.Body.String() != id {
+			t.Fatalf("charge in context: got %q, want %q", w.Body.String(), id)
+		}
+	})
+	t.Run("Callback", func(t *testing.T) {
+		srv, h := setup(t, routes)
+		w := serve(h, "/callback")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("missing charge_id: got %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		serve(h, "/paid")
+		w = serve(h, "/callback?charge_id="+srv.Charges()[0].ID)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("callback: got %d, want %d", w.Code, http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package satisgohttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+type contextKey struct{}
+
+//ChargeFromContext returns the paid charge that let the request through the Middleware
+func ChargeFromContext(ctx context.Context) (*satisgo.Charge, bool) {
+	c, ok := ctx.Value(contextKey{}).(*satisgo.Charge)
+	return c, ok
+}
+
+//NewContext returns a copy of ctx carrying the paid charge
+func NewContext(ctx context.Context, c *satisgo.Charge) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+//GateMetadataKey is the metadata key of the charges created by a Gate, its value is the Name of the gate
+const GateMetadataKey = "satisgo_gate"
+
+//Config configures the Middleware, Client, Name, User, Charge and Session are mandatory
+type Config struct {
+	Client satisgo.Client
+	//Name identifies the gate in the metadata of its charges: a charge paid for a gate does not open another one
+	Name string
+	//User resolves the Satispay user that has to pay for the request
+	User func(r *http.Request) (*satisgo.User, error)
+	//Charge fills the charge to create for the request (ammount, description, metadata...)
+	//A pending charge is accepted only if it has the same user, ammount and metadata:
+	//set a metadata with the route when the same gate protects routes with different contents
+	Charge func(r *http.Request, c *satisgo.Charge) error
+	//CallbackURL is set on the created charges
+	CallbackURL string
+	Session     Session
+	//Consume marks the paid charge as used and reports false if it has been used already,
+	//so a charge lets a single request through. When nil the ids are kept in memory
+	//for PaidFor: share them when the app runs on more instances
+	Consume func(r *http.Request, chargeID string) (bool, error)
+	//PaidFor is how long after the payment a charge can open the gate (1 hour by default, like CookieSession.MaxAge),
+	//a charge paid before needs a new payment
+	PaidFor time.Duration
+	//Pending writes the response while the payment is waiting (PendingResponse when nil)
+	Pending func(w http.ResponseWriter, r *http.Request, c *satisgo.Charge)
+	//Error writes the response when something goes wrong (ErrorResponse when nil)
+	Error func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+//Gate decides what to do with a request to a protected route
+//It is the core of Middleware, exposed for the framework adapters
+type Gate struct {
+	cfg Config
+
+	mu sync.Mutex
+	//consumed maps the used charges to the time they can be forgotten: they cannot open the gate anymore
+	consumed map[string]time.Time
+}
+
+//NewGate checks the configuration and generates a Gate
+func NewGate(cfg Config) (*Gate, error) {
+	if cfg.Client == nil {
+		return nil, fmt.Errorf("Client cannot be nil")
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("Name cannot be empty")
+	}
+	if cfg.User == nil || cfg.Charge == nil {
+		return nil, fmt.Errorf("User and Charge must be provided")
+	}
+	if cfg.Session == nil {
+		return nil, fmt.Errorf("Session cannot be nil")
+	}
+	if s, ok := cfg.Session.(*CookieSession); ok {
+		if err := s.check(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Pending == nil {
+		cfg.Pending = PendingResponse
+	}
+	if cfg.Error == nil {
+		cfg.Error = ErrorResponse
+	}
+	if cfg.PaidFor <= 0 {
+		cfg.PaidFor = time.Hour
+	}
+	g := &Gate{cfg: cfg, consumed: make(map[string]time.Time)}
+	if g.cfg.Consume == nil {
+		g.cfg.Consume = g.consume
+	}
+	return g, nil
+}
+
+//Check returns the paid charge of the request, when nil a response has been written already
+//(pending payment or error) and the request must not go on.
+//The charge of the session is accepted only if it is the one the gate would create for the request,
+//and only once: after that the session is cleared and the next request needs a new payment
+func (g *Gate) Check(w http.ResponseWriter, r *http.Request) *satisgo.Charge {
+	p := g.cfg.Client.BindContext(r.Context())
+	want, err := g.newCharge(r)
+	if err != nil {
+		g.cfg.Error(w, r, err)
+		return nil
+	}
+	id, err := g.cfg.Session.PendingCharge(r)
+	if err != nil {
+		g.cfg.Error(w, r, err)
+		return nil
+	}
+	if id != "" {
+		c, err := p.ChargeAPI().Get(id)
+		if err != nil {
+			g.cfg.Error(w, r, err)
+			return nil
+		}
+		if matchCharge(c, want) {
+			switch c.Status {
+			case satisgo.Required:
+				g.cfg.Pending(w, r, c)
+				return nil
+			case satisgo.Success:
+				if g.paidTooLongAgo(c) {
+					break
+				}
+				fresh, err := g.cfg.Consume(r, c.ID)
+				if err != nil {
+					g.cfg.Error(w, r, err)
+					return nil
+				}
+				if fresh {
+					err = g.cfg.Session.ClearPendingCharge(w, r)
+					if err != nil {
+						g.cfg.Error(w, r, err)
+						return nil
+					}
+					return c
+				}
+			}
+		}
+		//failed, canceled, used already, paid too long ago or not a charge of the gate for the request: a new charge is needed
+		err = g.cfg.Session.ClearPendingCharge(w, r)
+		if err != nil {
+			g.cfg.Error(w, r, err)
+			return nil
+		}
+	}
+	c, err := p.ChargeAPI().Create(satisgo.ChargeCreateParams{
+		UserID:      want.UserID,
+		Amount:      want.Amount,
+		CallbackURL: want.CallbackURL,
+		Description: want.Description,
+		Expire:      time.Duration(want.ExpireIn) * time.Second,
+		Metadata:    want.Metadata,
+	})
+	if err != nil {
+		g.cfg.Error(w, r, err)
+		return nil
+	}
+	err = g.cfg.Session.SetPendingCharge(w, r, c.ID)
+	if err != nil {
+		g.cfg.Error(w, r, err)
+		return nil
+	}
+	g.cfg.Pending(w, r, c)
+	return nil
+}
+
+//newCharge fills the charge the gate creates for the request
+func (g *Gate) newCharge(r *http.Request) (*satisgo.Charge, error) {
+	u, err := g.cfg.User(r)
+	if err != nil {
+		return nil, err
+	}
+	if u.ID == "" {
+		return nil, fmt.Errorf("not possible to create a charge if user_id is empty")
+	}
+	c := &satisgo.Charge{UserID: u.ID}
+	err = c.SetCallbackURL(g.cfg.CallbackURL)
+	if err != nil {
+		return nil, err
+	}
+	err = g.cfg.Charge(r, c)
+	if err != nil {
+		return nil, err
+	}
+	err = c.SetMetadata(GateMetadataKey, g.cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//matchCharge is true when c has the user, the ammount and the metadata of want
+func matchCharge(c, want *satisgo.Charge) bool {
+	if c.UserID != want.UserID || c.Amount != want.Amount {
+		return false
+	}
+	for k, v := range want.Metadata {
+		if c.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+//paidTooLongAgo is true when the charge has been paid more than PaidFor ago
+func (g *Gate) paidTooLongAgo(c *satisgo.Charge) bool {
+	t, err := time.Parse(time.RFC3339Nano, c.ChargeDate)
+	return err == nil && time.Since(t) > g.cfg.PaidFor
+}
+
+//consume remembers the charge for PaidFor: after that paidTooLongAgo rejects it and it is dropped
+func (g *Gate) consume(r *http.Request, chargeID string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	for id, until := range g.consumed {
+		if now.After(until) {
+			delete(g.consumed, id)
+		}
+	}
+	if _, ok := g.consumed[chargeID]; ok {
+		return false, nil
+	}
+	g.consumed[chargeID] = now.Add(g.cfg.PaidFor)
+	return true, nil
+}
+
+//Middleware lets the requests through only once the charge for them has been paid
+//The paid charge is available to the next handler with ChargeFromContext
+func Middleware(cfg Config) (func(http.Handler) http.Handler, error) {
+	g, err := NewGate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := g.Check(w, r)
+			if c == nil {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), c)))
+		})
+	}, nil
+}
+
+//pendingBody is the JSON body of PendingResponse
+type pendingBody struct {
+	ChargeID   string `json:"charge_id"`
+	Status     string `json:"status"`
+	Amount     uint64 `json:"amount"`
+	Currency   string `json:"currency"`
+	ExpireDate string `json:"expire_date,omitempty"`
+}
+
+var pendingPage = template.Must(template.New("pending").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta http-equiv="refresh" content="5"><title>Payment pending</title></head>
+<body>
+<h1>Payment pending</h1>
+<p>Approve the payment of {{.Price}} {{.Currency}} on the Satispay app, this page will refresh by itself.</p>
+</body>
+</html>
+`))
+
+//PendingResponse answers 402 Payment Required with JSON or an HTML page that refreshes itself,
+//depending on the Accept header
+func PendingResponse(w http.ResponseWriter, r *http.Request, c *satisgo.Charge) {
+	body := pendingBody{
+		ChargeID:   c.ID,
+		Status:     c.Status,
+		Amount:     c.Amount,
+		Currency:   c.Currency,
+		ExpireDate: c.ExpireDate,
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusPaymentRequired)
+	pendingPage.Execute(w, struct {
+		Price    string
+		Currency string
+	}{fmt.Sprintf("%.2f", float64(c.Amount)/100), c.Currency})
+}
+
+//ErrorResponse answers 502 Bad Gateway, the details are not sent to the client
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, "payment service unavailable", http.StatusBadGateway)
+}
@@ -0,0 +1,229 @@
+package satisgohttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgohttp"
+	"github.com/drymonsoon/satisgo/satisgohttp/internal/adaptertest"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+const (
+	alice = "+393331111111"
+	bob   = "+393332222222"
+)
+
+var key = []byte(strings.Repeat("k", 32))
+
+//gated serves /cheap (1 euro) and /expensive (5 euros) behind a gate, the user is the X-Phone header
+func gated(t *testing.T, srv *satisgotest.Server, name string, opts ...func(*satisgohttp.Config)) http.Handler {
+	p, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := satisgohttp.Config{
+		Client: p,
+		Name:   name,
+		User: func(r *http.Request) (*satisgo.User, error) {
+			return p.UserFromPhone(r.Header.Get("X-Phone"))
+		},
+		Charge: func(r *http.Request, c *satisgo.Charge) error {
+			if r.URL.Path == "/expensive" {
+				return c.SetAmmount(5)
+			}
+			return c.SetAmmount(1)
+		},
+		CallbackURL: "http://example.com/callback?charge_id={uuid}",
+		Session:     &satisgohttp.CookieSession{Key: key},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	mw, err := satisgohttp.Middleware(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := satisgohttp.ChargeFromContext(r.Context())
+		fmt.Fprint(w, c.ID)
+	}))
+}
+
+func get(h http.Handler, path, phone string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	r.Header.Set("X-Phone", phone)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+//pay gets a pending charge for the path and pays it, the cookie of the paid charge is returned
+func pay(t *testing.T, srv *satisgotest.Server, h http.Handler, path, phone string) (*http.Cookie, string) {
+	t.Helper()
+	w := get(h, path, phone)
+	if w.Code != http.StatusPaymentRequired || len(w.Result().Cookies()) != 1 {
+		t.Fatalf("first request: got %d and cookies %v", w.Code, w.Result().Cookies())
+	}
+	cookie := w.Result().Cookies()[0]
+	charges := srv.Charges()
+	id := charges[len(charges)-1].ID
+	srv.SetChargeStatus(id, satisgo.Success, "")
+	return cookie, id
+}
+
+func setup(t *testing.T) (*satisgotest.Server, http.Handler) {
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddUser(alice)
+	srv.AddUser(bob)
+	return srv, gated(t, srv, "content")
+}
+
+func TestMiddlewareSingleUse(t *testing.T) {
+	srv, h := setup(t)
+	cookie, id := pay(t, srv, h, "/cheap", alice)
+
+	w := get(h, "/cheap", alice, cookie)
+	if w.Code != http.StatusOK || w.Body.String() != id {
+		t.Fatalf("paid request: got %d %q", w.Code, w.Body.String())
+	}
+	cleared := w.Result().Cookies()
+	if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Fatalf("the cookie should be cleared once the charge is used, got %v", cleared)
+	}
+	//the same cookie again: the charge has been used already
+	w = get(h, "/cheap", alice, cookie)
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("replayed cookie: got %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+	if n := len(srv.Charges()); n != 2 {
+		t.Fatalf("got %d charges, want a new one for the replayed cookie", n)
+	}
+}
+
+func TestMiddlewarePaidFor(t *testing.T) {
+	srv, _ := setup(t)
+	h := gated(t, srv, "content", func(cfg *satisgohttp.Config) { cfg.PaidFor = 100 * time.Millisecond })
+	cookie, _ := pay(t, srv, h, "/cheap", alice)
+	time.Sleep(200 * time.Millisecond)
+	//the used charges are forgotten after PaidFor, so an older charge cannot open the gate
+	w := get(h, "/cheap", alice, cookie)
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("charge paid too long ago: got %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+}
+
+func TestMiddlewareForgedCookie(t *testing.T) {
+	srv, h := setup(t)
+	cookie, id := pay(t, srv, h, "/cheap", alice)
+	_, other := pay(t, srv, h, "/cheap", alice)
+	forged := []*http.Cookie{
+		{Name: cookie.Name, Value: other},
+		{Name: cookie.Name, Value: other + ".c2lnbmF0dXJl"},
+		//the signature of another charge
+		{Name: cookie.Name, Value: strings.Replace(cookie.Value, id, other, 1)},
+	}
+	for _, c := range forged {
+		w := get(h, "/cheap", alice, c)
+		if w.Code != http.StatusPaymentRequired {
+			t.Fatalf("forged cookie %q: got %d, want %d", c.Value, w.Code, http.StatusPaymentRequired)
+		}
+	}
+	//the signed cookie is still good
+	if w := get(h, "/cheap", alice, cookie); w.Code != http.StatusOK {
+		t.Fatalf("paid request: got %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMiddlewareWrongCharge(t *testing.T) {
+	srv, h := setup(t)
+	other := gated(t, srv, "other")
+	cookie, _ := pay(t, srv, h, "/cheap", alice)
+
+	if w := get(h, "/cheap", bob, cookie); w.Code != http.StatusPaymentRequired {
+		t.Fatalf("charge of another user: got %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+	if w := get(h, "/expensive", alice, cookie); w.Code != http.StatusPaymentRequired {
+		t.Fatalf("charge of a cheaper route: got %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+	if w := get(other, "/cheap", alice, cookie); w.Code != http.StatusPaymentRequired {
+		t.Fatalf("charge of another gate: got %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+	for _, c := range srv.Charges()[1:] {
+		if c.Status != satisgo.Required {
+			t.Fatalf("unexpected charge %+v", c)
+		}
+	}
+}
+
+func TestNewGate(t *testing.T) {
+	srv, _ := setup(t)
+	p, _ := srv.Client()
+	cfg := satisgohttp.Config{
+		Client:  p,
+		Name:    "content",
+		User:    func(r *http.Request) (*satisgo.User, error) { return nil, nil },
+		Charge:  func(r *http.Request, c *satisgo.Charge) error { return nil },
+		Session: &satisgohttp.CookieSession{},
+	}
+	if _, err := satisgohttp.NewGate(cfg); err == nil {
+		t.Fatal("a CookieSession without Key should be refused")
+	}
+	cfg.Session = &satisgohttp.CookieSession{Key: key}
+	cfg.Name = ""
+	if _, err := satisgohttp.NewGate(cfg); err == nil {
+		t.Fatal("a gate without Name should be refused")
+	}
+}
+
+func TestCallbackMock(t *testing.T) {
+	m := &satisgotest.Mock{
+		ChargesGetFunc: func(id string) (*satisgo.Charge, error) {
+			return &satisgo.Charge{ID: id, Status: satisgo.Success}, nil
+		},
+	}
+	var got *satisgo.Charge
+	cb := &satisgohttp.Callback{
+		Client: m,
+		OnCharge: func(r *http.Request, c *satisgo.Charge) error {
+			got = c
+			return nil
+		},
+	}
+	status := cb.Handle(httptest.NewRequest("POST", "/callback?charge_id=charge-id", nil))
+	if status != http.StatusNoContent || got == nil || got.ID != "charge-id" {
+		t.Fatalf("got %d and charge %+v", status, got)
+	}
+	if n := m.CallCount("BindContext"); n != 1 {
+		t.Fatalf("BindContext called %d times, want 1", n)
+	}
+}
+
+func TestAdapter(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, cfg satisgohttp.Config, cb *satisgohttp.Callback) http.Handler {
+		mw, err := satisgohttp.Middleware(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/paid", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := satisgohttp.ChargeFromContext(r.Context())
+			if !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, c.ID)
+		})))
+		mux.Handle("/callback", cb)
+		return mux
+	})
+}
@@ -0,0 +1,46 @@
+/*
+Package satisecho exposes the satisgohttp middleware and callback handler to Echo.
+
+*/
+package satisecho
+
+import (
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgohttp"
+	"github.com/labstack/echo/v4"
+)
+
+//ChargeKey is the key of the paid charge in the echo.Context
+const ChargeKey = "satisgo.charge"
+
+//Middleware lets the requests through only once the charge for them has been paid
+func Middleware(cfg satisgohttp.Config) (echo.MiddlewareFunc, error) {
+	g, err := satisgohttp.NewGate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ch := g.Check(c.Response(), c.Request())
+			if ch == nil {
+				return nil
+			}
+			c.Set(ChargeKey, ch)
+			c.SetRequest(c.Request().WithContext(satisgohttp.NewContext(c.Request().Context(), ch)))
+			return next(c)
+		}
+	}, nil
+}
+
+//Callback receives the notifications Satispay sends when a charge changes status
+func Callback(cb *satisgohttp.Callback) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.NoContent(cb.Handle(c.Request()))
+	}
+}
+
+//Charge returns the paid charge that let the request through the Middleware
+func Charge(c echo.Context) (*satisgo.Charge, bool) {
+	ch, ok := c.Get(ChargeKey).(*satisgo.Charge)
+	return ch, ok
+}
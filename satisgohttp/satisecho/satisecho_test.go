@@ -0,0 +1,30 @@
+package satisecho_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/drymonsoon/satisgo/satisgohttp"
+	"github.com/drymonsoon/satisgo/satisgohttp/internal/adaptertest"
+	"github.com/drymonsoon/satisgo/satisgohttp/satisecho"
+	"github.com/labstack/echo/v4"
+)
+
+func TestAdapter(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, cfg satisgohttp.Config, cb *satisgohttp.Callback) http.Handler {
+		mw, err := satisecho.Middleware(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := echo.New()
+		e.GET("/paid", func(c echo.Context) error {
+			ch, ok := satisecho.Charge(c)
+			if !ok {
+				return c.NoContent(http.StatusInternalServerError)
+			}
+			return c.String(http.StatusOK, ch.ID)
+		}, mw)
+		e.GET("/callback", satisecho.Callback(cb))
+		return e
+	})
+}
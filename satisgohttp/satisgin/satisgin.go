@@ -0,0 +1,49 @@
+/*
+Package satisgin exposes the satisgohttp middleware and callback handler to Gin.
+
+*/
+package satisgin
+
+import (
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgohttp"
+	"github.com/gin-gonic/gin"
+)
+
+//ChargeKey is the key of the paid charge in the gin.Context
+const ChargeKey = "satisgo.charge"
+
+//Middleware lets the requests through only once the charge for them has been paid
+func Middleware(cfg satisgohttp.Config) (gin.HandlerFunc, error) {
+	g, err := satisgohttp.NewGate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		ch := g.Check(c.Writer, c.Request)
+		if ch == nil {
+			c.Abort()
+			return
+		}
+		c.Set(ChargeKey, ch)
+		c.Request = c.Request.WithContext(satisgohttp.NewContext(c.Request.Context(), ch))
+		c.Next()
+	}, nil
+}
+
+//Callback receives the notifications Satispay sends when a charge changes status
+func Callback(cb *satisgohttp.Callback) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(cb.Handle(c.Request))
+	}
+}
+
+//Charge returns the paid charge that let the request through the Middleware
+func Charge(c *gin.Context) (*satisgo.Charge, bool) {
+	v, ok := c.Get(ChargeKey)
+	if !ok {
+		return nil, false
+	}
+	ch, ok := v.(*satisgo.Charge)
+	return ch, ok
+}
@@ -0,0 +1,32 @@
+package satisgin_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/drymonsoon/satisgo/satisgohttp"
+	"github.com/drymonsoon/satisgo/satisgohttp/internal/adaptertest"
+	"github.com/drymonsoon/satisgo/satisgohttp/satisgin"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdapter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	adaptertest.Run(t, func(t *testing.T, cfg satisgohttp.Config, cb *satisgohttp.Callback) http.Handler {
+		mw, err := satisgin.Middleware(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := gin.New()
+		e.GET("/paid", mw, func(c *gin.Context) {
+			ch, ok := satisgin.Charge(c)
+			if !ok {
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			c.String(http.StatusOK, ch.ID)
+		})
+		e.GET("/callback", satisgin.Callback(cb))
+		return e
+	})
+}
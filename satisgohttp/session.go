@@ -0,0 +1,121 @@
+package satisgohttp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//Session keeps the charge waiting for a payment between two requests of the same client
+type Session interface {
+	//PendingCharge returns the id of the charge of the request, empty if there is none
+	PendingCharge(r *http.Request) (string, error)
+	//SetPendingCharge binds the charge to the client of the request
+	SetPendingCharge(w http.ResponseWriter, r *http.Request, chargeID string) error
+	//ClearPendingCharge forgets the charge of the request
+	ClearPendingCharge(w http.ResponseWriter, r *http.Request) error
+}
+
+//CookieSession is a Session keeping the charge id in a cookie signed with HMAC-SHA256
+//A cookie without a valid signature is ignored, as if there was no pending charge
+type CookieSession struct {
+	//Key signs the cookie, at least 32 random bytes kept secret, mandatory
+	Key []byte
+	//Name of the cookie ("satisgo_charge" by default)
+	Name string
+	//Path of the cookie ("/" by default)
+	Path string
+	//MaxAge of the cookie (1 hour by default, the max expiration of a charge)
+	MaxAge time.Duration
+	//Secure makes the cookie https only
+	Secure bool
+}
+
+func (s *CookieSession) name() string {
+	if s.Name == "" {
+		return "satisgo_charge"
+	}
+	return s.Name
+}
+
+func (s *CookieSession) path() string {
+	if s.Path == "" {
+		return "/"
+	}
+	return s.Path
+}
+
+func (s *CookieSession) check() error {
+	if len(s.Key) < 32 {
+		return fmt.Errorf("CookieSession Key must be at least 32 bytes long")
+	}
+	return nil
+}
+
+//sign returns the signature of the charge id for the cookie
+func (s *CookieSession) sign(chargeID string) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(s.name() + "=" + chargeID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//PendingCharge implements Session
+func (s *CookieSession) PendingCharge(r *http.Request) (string, error) {
+	if err := s.check(); err != nil {
+		return "", err
+	}
+	c, err := r.Cookie(s.name())
+	if err == http.ErrNoCookie {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	i := strings.LastIndex(c.Value, ".")
+	if i < 0 {
+		return "", nil
+	}
+	id, sig := c.Value[:i], c.Value[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.sign(id))) {
+		return "", nil
+	}
+	return id, nil
+}
+
+//SetPendingCharge implements Session
+func (s *CookieSession) SetPendingCharge(w http.ResponseWriter, r *http.Request, chargeID string) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	age := s.MaxAge
+	if age <= 0 {
+		age = time.Hour
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name(),
+		Value:    chargeID + "." + s.sign(chargeID),
+		Path:     s.path(),
+		MaxAge:   int(age.Seconds()),
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+//ClearPendingCharge implements Session
+func (s *CookieSession) ClearPendingCharge(w http.ResponseWriter, r *http.Request) error {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name(),
+		Value:    "",
+		Path:     s.path(),
+		MaxAge:   -1,
+		Secure:   s.Secure,
+		HttpOnly: true,
+	})
+	return nil
+}
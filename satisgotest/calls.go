@@ -0,0 +1,40 @@
+package satisgotest
+
+import "errors"
+
+//go:generate go run ./internal/genmock -in ../client.go -out mock.go
+
+//ErrNotMocked is returned by the methods of a Mock without their function
+var ErrNotMocked = errors.New("satisgotest: method not mocked")
+
+//Call is a call received by a Mock
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+func (m *Mock) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+	m.mu.Unlock()
+}
+
+//Calls returns the calls received by m, in order
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+//CallCount is the number of calls received by the method, ex. "Charges.Create"
+func (m *Mock) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, c := range m.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,266 @@
+package satisgotest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//Mode tells if a Cassette records the calls to the API or replays them
+type Mode int
+
+const (
+	//Record sends the calls to the API and keeps them, Save writes them in the cassette file
+	Record Mode = iota
+	//Replay answers the calls with the ones in the cassette file, nothing is sent
+	Replay
+)
+
+//Interaction is a request to the API and its response, as stored in a cassette file
+type Interaction struct {
+	Request struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query,omitempty"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body,omitempty"`
+	} `json:"response"`
+}
+
+//Cassette records the calls made by a client to a file and replays them in the tests, offline.
+//The bearer is never stored and the phone numbers are replaced by fake ones (starting with +999):
+//the Digest and Content-Length of the stored responses are computed again after the redaction.
+//
+//	c, err := satisgotest.NewCassette("testdata/checkout.json", satisgotest.Replay)
+//	p, err := satisgo.New(bearer, "staging", satisgo.WithMiddleware(c.Middleware()))
+//
+//In Replay a request matches an interaction with the same method, path, query and body (the JSON is compared
+//by value), each interaction is used once and in order.
+//The replayed responses have no Date and no Signature: the recorded ones get old and do not cover the redacted bodies.
+//Replay with a client without WithSignatureVerifier, and without a Date check in its IntegrityPolicy
+type Cassette struct {
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+//NewCassette returns a cassette for the file at path, which is read in Replay mode
+func NewCassette(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	if mode == Record {
+		return c, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &c.interactions)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the cassette %s: %s", path, err.Error())
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+//Save writes the interactions recorded in the cassette file
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), os.FileMode(0644))
+}
+
+//Middleware records or replays the calls of the client, give it to satisgo.WithMiddleware
+func (c *Cassette) Middleware() satisgo.Middleware {
+	return func(next satisgo.Doer) satisgo.Doer {
+		if c.mode == Replay {
+			return satisgo.DoerFunc(c.replay)
+		}
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return c.record(next, req)
+		})
+	}
+}
+
+func (c *Cassette) record(next satisgo.Doer, req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	bearer := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	redact := func(s string) string {
+		if bearer != "" {
+			s = strings.Replace(s, bearer, "REDACTED", -1)
+		}
+		return phoneRegexp.ReplaceAllStringFunc(s, fakePhone)
+	}
+	var it Interaction
+	it.Request.Method = req.Method
+	it.Request.Path = req.URL.Path
+	it.Request.Query = redact(req.URL.RawQuery)
+	it.Request.Body = redact(string(body))
+	it.Response.StatusCode = resp.StatusCode
+	it.Response.Header = resp.Header.Clone()
+	it.Response.Header.Del("Set-Cookie")
+	it.Response.Body = redact(string(respBody))
+	if it.Response.Body != string(respBody) {
+		//the signature cannot be valid for the redacted body
+		it.Response.Header.Del("Signature")
+		setIntegrity(it.Response.Header, []byte(it.Response.Body))
+	}
+	c.mu.Lock()
+	c.interactions = append(c.interactions, it)
+	c.mu.Unlock()
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	//the phones of the test are redacted to match, then given back in the response
+	phones := make(map[string]string)
+	redact := func(s string) string {
+		return phoneRegexp.ReplaceAllStringFunc(s, func(p string) string {
+			f := fakePhone(p)
+			phones[f] = p
+			return f
+		})
+	}
+	query := redact(req.URL.RawQuery)
+	reqBody := redact(string(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.interactions {
+		it := &c.interactions[i]
+		if c.used[i] || it.Request.Method != req.Method || it.Request.Path != req.URL.Path ||
+			!sameQuery(it.Request.Query, query) || !sameBody(it.Request.Body, reqBody) {
+			continue
+		}
+		c.used[i] = true
+		respBody := it.Response.Body
+		for fake, p := range phones {
+			respBody = strings.Replace(respBody, fake, p, -1)
+		}
+		h := it.Response.Header.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		//the recorded date would fail any freshness check, and the signature covers it
+		h.Del("Date")
+		h.Del("Signature")
+		if respBody != it.Response.Body {
+			setIntegrity(h, []byte(respBody))
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", it.Response.StatusCode, http.StatusText(it.Response.StatusCode)),
+			StatusCode:    it.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        h,
+			Body:          ioutil.NopCloser(strings.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("No interaction left in the cassette %s for %s %s", c.path, req.Method, req.URL.Path)
+}
+
+//readRequestBody reads the body of req and leaves it readable
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+//phoneRegexp finds the phone numbers in E.164 format, the fake ones excluded
+var phoneRegexp = regexp.MustCompile(`\+[1-9][0-9]{7,14}`)
+
+//fakePhone replaces a phone number with a fake one, always the same for the same number
+func fakePhone(p string) string {
+	if strings.HasPrefix(p, "+999") {
+		return p
+	}
+	sum := sha256.Sum256([]byte(p))
+	n := new(big.Int).SetBytes(sum[:8])
+	return fmt.Sprintf("+999%09d", n.Mod(n, big.NewInt(1000000000)))
+}
+
+//setIntegrity sets Content-Length and Digest for body, with the algorithm used by Satispay
+func setIntegrity(h http.Header, body []byte) {
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	if h.Get("Digest") != "" {
+		sum := sha512.Sum512(body)
+		h.Set("Digest", "SHA-512="+base64.StdEncoding.EncodeToString(sum[:]))
+	}
+}
+
+func sameQuery(a, b string) bool {
+	qa, err := url.ParseQuery(a)
+	if err != nil {
+		return a == b
+	}
+	qb, err := url.ParseQuery(b)
+	if err != nil {
+		return false
+	}
+	return qa.Encode() == qb.Encode()
+}
+
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
@@ -0,0 +1,150 @@
+package satisgotest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+const phone = "+393331234567"
+
+func TestCassetteRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cassette.json")
+	srv := satisgotest.NewServer()
+	u := srv.AddUser(phone)
+
+	rec, err := satisgotest.NewCassette(file, satisgotest.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := srv.Client(satisgo.WithMiddleware(rec.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := p.UserFromPhone(phone)
+	if err != nil || found.ID != u.ID {
+		t.Fatalf("got %+v, %v", found, err)
+	}
+	c, err := p.Charges.Create(satisgo.ChargeCreateParams{
+		UserID:      u.ID,
+		Amount:      500,
+		CallbackURL: "https://example.com/callback?charge_id={uuid}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), srv.Bearer) || strings.Contains(string(data), phone) {
+		t.Fatalf("the cassette contains the bearer or the phone number:\n%s", data)
+	}
+
+	//the server is closed: everything comes from the cassette
+	play, err := satisgotest.NewCassette(file, satisgotest.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = satisgo.New("another-token", "staging", satisgo.WithBaseURL(srv.URL), satisgo.WithMiddleware(play.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = p.UserFromPhone(phone)
+	if err != nil || found.ID != u.ID || found.Phone != phone {
+		t.Fatalf("replayed user: got %+v, %v", found, err)
+	}
+	//the idempotency key is not part of the match
+	replayed, err := p.Charges.Create(satisgo.ChargeCreateParams{
+		UserID:      u.ID,
+		Amount:      500,
+		CallbackURL: "https://example.com/callback?charge_id={uuid}",
+	})
+	if err != nil || replayed.ID != c.ID {
+		t.Fatalf("replayed charge: got %+v, %v", replayed, err)
+	}
+	if _, err := p.Charges.Get(c.ID); err == nil {
+		t.Fatal("a call not recorded should fail")
+	}
+}
+
+func TestCassetteReplaySignature(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cassette.json")
+	srv := satisgotest.NewServer()
+	srv.AddUser(phone)
+	rec, err := satisgotest.NewCassette(file, satisgotest.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := srv.Client(satisgo.WithMiddleware(rec.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.UserFromPhone(phone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	//a signature recorded with the fake phone number, it is not valid once the real one is given back
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var its []satisgotest.Interaction
+	err = json.Unmarshal(data, &its)
+	if err != nil || len(its) != 1 {
+		t.Fatalf("got %d interactions, %v", len(its), err)
+	}
+	its[0].Response.Header.Set("Signature", `keyId="satispay",signature="c2ln"`)
+	data, _ = json.Marshal(its)
+	err = os.WriteFile(file, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	play, err := satisgotest.NewCassette(file, satisgotest.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signature, date []string
+	inspect := func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.Do(req)
+			if err == nil {
+				signature = resp.Header.Values("Signature")
+				date = resp.Header.Values("Date")
+			}
+			return resp, err
+		})
+	}
+	p, err = satisgo.New("token", "staging", satisgo.WithMiddleware(inspect, play.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.UserFromPhone(phone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signature) != 0 {
+		t.Fatalf("got Signature %q, want it removed", signature)
+	}
+	if len(date) != 0 {
+		t.Fatalf("got Date %q, want it removed", date)
+	}
+}
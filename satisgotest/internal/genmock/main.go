@@ -0,0 +1,374 @@
+/*
+Genmock generates the satisgotest.Mock from the Client interface of satisgo.
+
+	go run ./internal/genmock -in ../client.go -out mock.go
+
+Every method of Client gets a <Method>Func field in the Mock. The methods returning one of the API interfaces
+of the file (ex. ChargeAPI) return a service of the Mock, whose methods get a <Service><Method>Func field
+(ex. ChargesCreateFunc for ChargeAPI().Create). The methods without their function return ErrNotMocked,
+the ones returning a Client return the Mock itself.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	//satisgoPath is the import path of the package of the Client interface
+	satisgoPath = "github.com/drymonsoon/satisgo"
+	//mockPackage is the package of the generated Mock
+	mockPackage = "satisgotest"
+	//selfZero stands for the Mock returned by the methods returning a Client
+	selfZero = "<mock>"
+)
+
+func main() {
+	in := flag.String("in", "client.go", "file of satisgo with the Client interface")
+	out := flag.String("out", "mock.go", "file of the generated Mock")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("genmock: ")
+
+	src, err := ioutil.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	code, err := generate(filepath.Base(*in), src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile(*out, code, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+//method is a method of an interface of the file, with its types written for the Mock package
+type method struct {
+	name string
+	//params are the declared parameters, args the values passed on (with ... for a variadic one)
+	params string
+	args   []string
+	//record are the values recorded in the Call
+	record  []string
+	funcTyp string
+	results string
+	//zeros are returned when the function is not set
+	zeros []string
+	//self is true when the method returns a Client: the Mock itself is returned when the function is not set
+	self bool
+}
+
+//service is a method of Client returning one of the API interfaces
+type service struct {
+	accessor string
+	iface    string
+	//name prefixes the fields and the calls of the methods (ex. Charges)
+	name    string
+	methods []method
+}
+
+type generator struct {
+	ifaces  map[string]*ast.InterfaceType
+	imports map[string]string
+	used    map[string]bool
+}
+
+//generate returns the source of the Mock of the Client interface declared in src
+func generate(name string, src []byte) ([]byte, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), name, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		ifaces:  make(map[string]*ast.InterfaceType),
+		imports: make(map[string]string),
+		used:    make(map[string]bool),
+	}
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		local := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			local = imp.Name.Name
+		}
+		g.imports[local] = path
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			if it, ok := ts.Type.(*ast.InterfaceType); ok {
+				g.ifaces[ts.Name.Name] = it
+			}
+		}
+		return true
+	})
+	client, ok := g.ifaces["Client"]
+	if !ok {
+		return nil, fmt.Errorf("No Client interface in %s", name)
+	}
+
+	var top []method
+	var services []service
+	for _, field := range client.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("Client embeds %s: only methods are supported", g.typ(field.Type))
+		}
+		mname := field.Names[0].Name
+		if iface := g.apiResult(ft); iface != "" {
+			s := service{accessor: mname, iface: iface, name: strings.TrimSuffix(iface, "API") + "s"}
+			for _, sf := range g.ifaces[iface].Methods.List {
+				sft, ok := sf.Type.(*ast.FuncType)
+				if !ok || len(sf.Names) == 0 {
+					return nil, fmt.Errorf("%s embeds %s: only methods are supported", iface, g.typ(sf.Type))
+				}
+				m, err := g.method(sf.Names[0].Name, sft)
+				if err != nil {
+					return nil, err
+				}
+				s.methods = append(s.methods, m)
+			}
+			services = append(services, s)
+			continue
+		}
+		m, err := g.method(mname, ft)
+		if err != nil {
+			return nil, err
+		}
+		top = append(top, m)
+	}
+
+	var b bytes.Buffer
+	g.write(&b, top, services)
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "// Code generated by genmock from %s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", name, mockPackage)
+	paths := []string{"sync"}
+	for local := range g.used {
+		paths = append(paths, g.imports[local])
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Fprintf(&head, "\t%q\n", p)
+	}
+	fmt.Fprintf(&head, "\n\t%q\n)\n", satisgoPath)
+	head.Write(b.Bytes())
+	return format.Source(head.Bytes())
+}
+
+//apiResult is the name of the API interface returned by the method, empty when it returns something else
+func (g *generator) apiResult(ft *ast.FuncType) string {
+	if len(ft.Params.List) != 0 || ft.Results == nil || len(ft.Results.List) != 1 {
+		return ""
+	}
+	id, ok := ft.Results.List[0].Type.(*ast.Ident)
+	if !ok || id.Name == "Client" || g.ifaces[id.Name] == nil {
+		return ""
+	}
+	return id.Name
+}
+
+func (g *generator) method(name string, ft *ast.FuncType) (method, error) {
+	m := method{name: name}
+	var params []string
+	i := 0
+	for _, field := range ft.Params.List {
+		typ := g.typ(field.Type)
+		names := make([]string, 0, len(field.Names))
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, fmt.Sprintf("p%d", i))
+		}
+		for _, n := range names {
+			m.record = append(m.record, n)
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				m.args = append(m.args, n+"...")
+			} else {
+				m.args = append(m.args, n)
+			}
+			i++
+		}
+		params = append(params, strings.Join(names, ", ")+" "+typ)
+	}
+	m.params = "(" + strings.Join(params, ", ") + ")"
+
+	var results []string
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for j := 0; j < n; j++ {
+				results = append(results, g.typ(field.Type))
+				if id, ok := field.Type.(*ast.Ident); ok && id.Name == "Client" {
+					m.self = true
+				}
+				z, err := g.zero(field.Type)
+				if err != nil {
+					return m, fmt.Errorf("Method %s: %s", name, err.Error())
+				}
+				m.zeros = append(m.zeros, z)
+			}
+		}
+	}
+	switch len(results) {
+	case 0:
+	case 1:
+		m.results = " " + results[0]
+	default:
+		m.results = " (" + strings.Join(results, ", ") + ")"
+	}
+	m.funcTyp = "func" + m.params + m.results
+	return m, nil
+}
+
+//typ writes the type for the Mock package: the types of satisgo are qualified
+func (g *generator) typ(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(t.Name) != nil {
+			return t.Name
+		}
+		return "satisgo." + t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			g.used[x.Name] = true
+			return x.Name + "." + t.Sel.Name
+		}
+	case *ast.StarExpr:
+		return "*" + g.typ(t.X)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + g.typ(t.Elt)
+		}
+		if l, ok := t.Len.(*ast.BasicLit); ok {
+			return "[" + l.Value + "]" + g.typ(t.Elt)
+		}
+	case *ast.MapType:
+		return "map[" + g.typ(t.Key) + "]" + g.typ(t.Value)
+	case *ast.Ellipsis:
+		return "..." + g.typ(t.Elt)
+	case *ast.InterfaceType:
+		if len(t.Methods.List) == 0 {
+			return "interface{}"
+		}
+	}
+	return fmt.Sprintf("/* unsupported type %T */", e)
+}
+
+//zero is the value returned for a result when the function is not set, ErrNotMocked for an error
+func (g *generator) zero(e ast.Expr) (string, error) {
+	switch t := e.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "error":
+			return "ErrNotMocked", nil
+		case "Client":
+			return selfZero, nil
+		case "bool":
+			return "false", nil
+		case "string":
+			return `""`, nil
+		}
+		if o := types.Universe.Lookup(t.Name); o != nil {
+			if b, ok := o.Type().(*types.Basic); ok && b.Info()&types.IsNumeric != 0 {
+				return "0", nil
+			}
+			return "nil", nil
+		}
+		if g.ifaces[t.Name] != nil {
+			return "nil", nil
+		}
+		return g.typ(t) + "{}", nil
+	case *ast.SelectorExpr:
+		return g.typ(t) + "{}", nil
+	case *ast.StarExpr, *ast.MapType, *ast.InterfaceType, *ast.FuncType, *ast.ChanType:
+		return "nil", nil
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil", nil
+		}
+		return g.typ(t) + "{}", nil
+	}
+	return "", fmt.Errorf("no zero value for %s", g.typ(e))
+}
+
+const mockDoc = `//Mock is a satisgo.Client whose methods call the functions set in it, named after the service and the method
+//(ex. ChargesCreateFunc for m.ChargeAPI().Create), the methods without a function return ErrNotMocked.
+//Every call is recorded, see Calls
+//
+//	m := &satisgotest.Mock{
+//		UsersLookupFunc: func(phone string) (*satisgo.User, error) {
+//			return &satisgo.User{ID: "user-id", Phone: phone}, nil
+//		},
+//	}
+//	checkout := NewCheckout(m)
+//
+//Mock is generated from satisgo.Client by internal/genmock: run go generate after changing the interface
+`
+
+func (g *generator) write(b *bytes.Buffer, top []method, services []service) {
+	b.WriteString("\n" + mockDoc + "type Mock struct {\n")
+	for _, m := range top {
+		if m.self {
+			fmt.Fprintf(b, "\t//%sFunc is called by %s, which returns m itself when it is nil\n", m.name, m.name)
+		}
+		fmt.Fprintf(b, "\t%sFunc %s\n", m.name, m.funcTyp)
+	}
+	for _, s := range services {
+		b.WriteString("\n")
+		for _, m := range s.methods {
+			fmt.Fprintf(b, "\t%s%sFunc %s\n", s.name, m.name, m.funcTyp)
+		}
+	}
+	b.WriteString("\n\tmu    sync.Mutex\n\tcalls []Call\n}\n\nvar _ satisgo.Client = (*Mock)(nil)\n")
+
+	for _, m := range top {
+		fmt.Fprintf(b, "\n//%s implements satisgo.Client\nfunc (m *Mock) %s%s%s {\n", m.name, m.name, m.params, m.results)
+		writeBody(b, "m", m.name, m.name+"Func", m)
+	}
+	for _, s := range services {
+		typ := "mock" + s.name
+		fmt.Fprintf(b, "\ntype %s struct{ m *Mock }\n", typ)
+		fmt.Fprintf(b, "\n//%s implements satisgo.Client, the calls are recorded as %s.Method\n", s.accessor, s.name)
+		fmt.Fprintf(b, "func (m *Mock) %s() satisgo.%s {\n\treturn %s{m}\n}\n", s.accessor, s.iface, typ)
+		for _, m := range s.methods {
+			fmt.Fprintf(b, "\nfunc (s %s) %s%s%s {\n", typ, m.name, m.params, m.results)
+			writeBody(b, "s.m", s.name+"."+m.name, s.name+m.name+"Func", m)
+		}
+	}
+}
+
+//writeBody writes the body of a method of the Mock, recv is the expression of the *Mock
+func writeBody(b *bytes.Buffer, recv, call, field string, m method) {
+	fmt.Fprintf(b, "\t%s.record(%s)\n", recv, strings.Join(append([]string{strconv.Quote(call)}, m.record...), ", "))
+	fn := recv + "." + field
+	args := strings.Join(m.args, ", ")
+	if m.results == "" {
+		fmt.Fprintf(b, "\tif %s != nil {\n\t\t%s(%s)\n\t}\n}\n", fn, fn, args)
+		return
+	}
+	zeros := make([]string, len(m.zeros))
+	for i, z := range m.zeros {
+		if z == selfZero {
+			z = recv
+		}
+		zeros[i] = z
+	}
+	fmt.Fprintf(b, "\tif %s == nil {\n\t\treturn %s\n\t}\n\treturn %s(%s)\n}\n", fn, strings.Join(zeros, ", "), fn, args)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMockUpToDate(t *testing.T) {
+	src, err := ioutil.ReadFile("../../../client.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate("client.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock, err := ioutil.ReadFile("../../mock.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, mock) {
+		t.Fatal("satisgotest/mock.go is out of date: run go generate in satisgotest")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src := `package satisgo
+
+import (
+	"context"
+	"time"
+)
+
+type Client interface {
+	BindContext(ctx context.Context) Client
+	Ping()
+	Count(kind string, since time.Time) (int, error)
+	ThingAPI() ThingAPI
+}
+
+type ThingAPI interface {
+	Tag(id string, tags ...string) (Thing, error)
+}
+`
+	code, err := generate("client.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\t\"context\"\n\t\"sync\"\n\t\"time\"\n",
+		"PingFunc        func()\n",
+		"ThingsTagFunc func(id string, tags ...string) (satisgo.Thing, error)\n",
+		"return 0, ErrNotMocked",
+		"return satisgo.Thing{}, ErrNotMocked",
+		"return s.m.ThingsTagFunc(id, tags...)",
+		`s.m.record("Things.Tag", id, tags)`,
+		"if m.BindContextFunc == nil {\n\t\treturn m\n\t}",
+		"if m.PingFunc != nil {\n\t\tm.PingFunc()\n\t}",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("the generated code misses %q:\n%s", want, code)
+		}
+	}
+	_, err = generate("client.go", []byte("package satisgo\n"))
+	if err == nil {
+		t.Fatal("a file without Client should be refused")
+	}
+}
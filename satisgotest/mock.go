@@ -0,0 +1,264 @@
+// Code generated by genmock from client.go. DO NOT EDIT.
+
+package satisgotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+// Mock is a satisgo.Client whose methods call the functions set in it, named after the service and the method
+// (ex. ChargesCreateFunc for m.ChargeAPI().Create), the methods without a function return ErrNotMocked.
+// Every call is recorded, see Calls
+//
+//	m := &satisgotest.Mock{
+//		UsersLookupFunc: func(phone string) (*satisgo.User, error) {
+//			return &satisgo.User{ID: "user-id", Phone: phone}, nil
+//		},
+//	}
+//	checkout := NewCheckout(m)
+//
+// Mock is generated from satisgo.Client by internal/genmock: run go generate after changing the interface
+type Mock struct {
+	//BindContextFunc is called by BindContext, which returns m itself when it is nil
+	BindContextFunc func(ctx context.Context) satisgo.Client
+	VerifyFunc      func() error
+
+	UsersGetFunc    func(id string) (*satisgo.User, error)
+	UsersLookupFunc func(phone string) (*satisgo.User, error)
+	UsersListFunc   func() ([]satisgo.User, error)
+
+	ChargesGetFunc    func(id string) (*satisgo.Charge, error)
+	ChargesCreateFunc func(params satisgo.ChargeCreateParams) (*satisgo.Charge, error)
+	ChargesCancelFunc func(id string) (*satisgo.Charge, error)
+	ChargesUpdateFunc func(id string, params satisgo.ChargeUpdateParams) (*satisgo.Charge, error)
+	ChargesListFunc   func(params *satisgo.ChargeListParams) ([]satisgo.Charge, error)
+
+	RefundsGetFunc    func(id string) (*satisgo.Refund, error)
+	RefundsCreateFunc func(params satisgo.RefundCreateParams) (*satisgo.Refund, error)
+	RefundsUpdateFunc func(id string, params satisgo.RefundUpdateParams) (*satisgo.Refund, error)
+	RefundsListFunc   func(params *satisgo.RefundListParams) ([]satisgo.Refund, error)
+
+	AmountsTodayFunc     func() (*satisgo.Ammount, error)
+	AmountsYesterdayFunc func() (*satisgo.Ammount, error)
+	AmountsDateFunc      func(year, month, day int) (*satisgo.Ammount, error)
+	AmountsThisWeekFunc  func() (*satisgo.Ammount, error)
+	AmountsThisMonthFunc func() (*satisgo.Ammount, error)
+	AmountsThisYearFunc  func() (*satisgo.Ammount, error)
+	AmountsYearFunc      func(year int) (*satisgo.Ammount, error)
+	AmountsRangeFunc     func(from, to time.Time) (*satisgo.Ammount, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ satisgo.Client = (*Mock)(nil)
+
+// BindContext implements satisgo.Client
+func (m *Mock) BindContext(ctx context.Context) satisgo.Client {
+	m.record("BindContext", ctx)
+	if m.BindContextFunc == nil {
+		return m
+	}
+	return m.BindContextFunc(ctx)
+}
+
+// Verify implements satisgo.Client
+func (m *Mock) Verify() error {
+	m.record("Verify")
+	if m.VerifyFunc == nil {
+		return ErrNotMocked
+	}
+	return m.VerifyFunc()
+}
+
+type mockUsers struct{ m *Mock }
+
+// UserAPI implements satisgo.Client, the calls are recorded as Users.Method
+func (m *Mock) UserAPI() satisgo.UserAPI {
+	return mockUsers{m}
+}
+
+func (s mockUsers) Get(id string) (*satisgo.User, error) {
+	s.m.record("Users.Get", id)
+	if s.m.UsersGetFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.UsersGetFunc(id)
+}
+
+func (s mockUsers) Lookup(phone string) (*satisgo.User, error) {
+	s.m.record("Users.Lookup", phone)
+	if s.m.UsersLookupFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.UsersLookupFunc(phone)
+}
+
+func (s mockUsers) List() ([]satisgo.User, error) {
+	s.m.record("Users.List")
+	if s.m.UsersListFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.UsersListFunc()
+}
+
+type mockCharges struct{ m *Mock }
+
+// ChargeAPI implements satisgo.Client, the calls are recorded as Charges.Method
+func (m *Mock) ChargeAPI() satisgo.ChargeAPI {
+	return mockCharges{m}
+}
+
+func (s mockCharges) Get(id string) (*satisgo.Charge, error) {
+	s.m.record("Charges.Get", id)
+	if s.m.ChargesGetFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.ChargesGetFunc(id)
+}
+
+func (s mockCharges) Create(params satisgo.ChargeCreateParams) (*satisgo.Charge, error) {
+	s.m.record("Charges.Create", params)
+	if s.m.ChargesCreateFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.ChargesCreateFunc(params)
+}
+
+func (s mockCharges) Cancel(id string) (*satisgo.Charge, error) {
+	s.m.record("Charges.Cancel", id)
+	if s.m.ChargesCancelFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.ChargesCancelFunc(id)
+}
+
+func (s mockCharges) Update(id string, params satisgo.ChargeUpdateParams) (*satisgo.Charge, error) {
+	s.m.record("Charges.Update", id, params)
+	if s.m.ChargesUpdateFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.ChargesUpdateFunc(id, params)
+}
+
+func (s mockCharges) List(params *satisgo.ChargeListParams) ([]satisgo.Charge, error) {
+	s.m.record("Charges.List", params)
+	if s.m.ChargesListFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.ChargesListFunc(params)
+}
+
+type mockRefunds struct{ m *Mock }
+
+// RefundAPI implements satisgo.Client, the calls are recorded as Refunds.Method
+func (m *Mock) RefundAPI() satisgo.RefundAPI {
+	return mockRefunds{m}
+}
+
+func (s mockRefunds) Get(id string) (*satisgo.Refund, error) {
+	s.m.record("Refunds.Get", id)
+	if s.m.RefundsGetFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.RefundsGetFunc(id)
+}
+
+func (s mockRefunds) Create(params satisgo.RefundCreateParams) (*satisgo.Refund, error) {
+	s.m.record("Refunds.Create", params)
+	if s.m.RefundsCreateFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.RefundsCreateFunc(params)
+}
+
+func (s mockRefunds) Update(id string, params satisgo.RefundUpdateParams) (*satisgo.Refund, error) {
+	s.m.record("Refunds.Update", id, params)
+	if s.m.RefundsUpdateFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.RefundsUpdateFunc(id, params)
+}
+
+func (s mockRefunds) List(params *satisgo.RefundListParams) ([]satisgo.Refund, error) {
+	s.m.record("Refunds.List", params)
+	if s.m.RefundsListFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.RefundsListFunc(params)
+}
+
+type mockAmounts struct{ m *Mock }
+
+// AmountAPI implements satisgo.Client, the calls are recorded as Amounts.Method
+func (m *Mock) AmountAPI() satisgo.AmountAPI {
+	return mockAmounts{m}
+}
+
+func (s mockAmounts) Today() (*satisgo.Ammount, error) {
+	s.m.record("Amounts.Today")
+	if s.m.AmountsTodayFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsTodayFunc()
+}
+
+func (s mockAmounts) Yesterday() (*satisgo.Ammount, error) {
+	s.m.record("Amounts.Yesterday")
+	if s.m.AmountsYesterdayFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsYesterdayFunc()
+}
+
+func (s mockAmounts) Date(year, month, day int) (*satisgo.Ammount, error) {
+	s.m.record("Amounts.Date", year, month, day)
+	if s.m.AmountsDateFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsDateFunc(year, month, day)
+}
+
+func (s mockAmounts) ThisWeek() (*satisgo.Ammount, error) {
+	s.m.record("Amounts.ThisWeek")
+	if s.m.AmountsThisWeekFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsThisWeekFunc()
+}
+
+func (s mockAmounts) ThisMonth() (*satisgo.Ammount, error) {
+	s.m.record("Amounts.ThisMonth")
+	if s.m.AmountsThisMonthFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsThisMonthFunc()
+}
+
+func (s mockAmounts) ThisYear() (*satisgo.Ammount, error) {
+	s.m.record("Amounts.ThisYear")
+	if s.m.AmountsThisYearFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsThisYearFunc()
+}
+
+func (s mockAmounts) Year(year int) (*satisgo.Ammount, error) {
+	s.m.record("Amounts.Year", year)
+	if s.m.AmountsYearFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsYearFunc(year)
+}
+
+func (s mockAmounts) Range(from, to time.Time) (*satisgo.Ammount, error) {
+	s.m.record("Amounts.Range", from, to)
+	if s.m.AmountsRangeFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return s.m.AmountsRangeFunc(from, to)
+}
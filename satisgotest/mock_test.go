@@ -0,0 +1,153 @@
+package satisgotest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+//checkout is the kind of consumer code depending on satisgo.Client
+func checkout(c satisgo.Client, phone string, amount uint64) (*satisgo.Charge, error) {
+	u, err := c.UserAPI().Lookup(phone)
+	if err != nil {
+		return nil, err
+	}
+	return c.ChargeAPI().Create(satisgo.ChargeCreateParams{
+		UserID:      u.ID,
+		Amount:      amount,
+		CallbackURL: "https://example.com/callback?charge_id={uuid}",
+	})
+}
+
+func TestMock(t *testing.T) {
+	m := &satisgotest.Mock{
+		UsersLookupFunc: func(phone string) (*satisgo.User, error) {
+			return &satisgo.User{ID: "user-id", Phone: phone}, nil
+		},
+		ChargesCreateFunc: func(params satisgo.ChargeCreateParams) (*satisgo.Charge, error) {
+			return &satisgo.Charge{ID: "charge-id", Status: satisgo.Required, Amount: params.Amount}, nil
+		},
+	}
+	ch, err := checkout(m, "+393331234567", 350)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.ID != "charge-id" || ch.Amount != 350 {
+		t.Fatalf("unexpected charge %+v", ch)
+	}
+	if n := m.CallCount("Charges.Create"); n != 1 {
+		t.Fatalf("Charges.Create called %d times, want 1", n)
+	}
+	calls := m.Calls()
+	if len(calls) != 2 || calls[0].Method != "Users.Lookup" || calls[0].Args[0] != "+393331234567" {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+	_, err = m.ChargeAPI().Get("charge-id")
+	if !errors.Is(err, satisgotest.ErrNotMocked) {
+		t.Fatalf("got %v, want ErrNotMocked", err)
+	}
+}
+
+func TestServerClient(t *testing.T) {
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	srv.AddUser("+393331234567")
+	p, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := checkout(p, "+39 333 123 4567", 350)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Charges(); len(got) != 1 || got[0].ID != ch.ID {
+		t.Fatalf("unexpected charges on the server %+v", got)
+	}
+}
+
+func TestServerServices(t *testing.T) {
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	srv.AddUser("+393331234567")
+	p, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := p.Users.Lookup("+39 333 123 4567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := p.Charges.Create(satisgo.ChargeCreateParams{
+		UserID:      u.ID,
+		Amount:      350,
+		CallbackURL: "https://example.com/callback?charge_id={uuid}",
+		Metadata:    map[string]string{"order": "42"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := "coffee"
+	ch, err = p.WithContext(context.Background()).Charges.Update(ch.ID, satisgo.ChargeUpdateParams{Description: &desc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.Description != desc || ch.Metadata["order"] != "42" {
+		t.Fatalf("unexpected charge %+v", ch)
+	}
+	ch, err = p.Charges.Cancel(ch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.Status != satisgo.Failure {
+		t.Fatalf("got status %s, want %s", ch.Status, satisgo.Failure)
+	}
+	list, err := p.Charges.List(&satisgo.ChargeListParams{Status: satisgo.Required})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("got %d charges REQUIRED, want 0", len(list))
+	}
+}
+
+func TestClientBindContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m := &satisgotest.Mock{
+		UsersLookupFunc: func(phone string) (*satisgo.User, error) {
+			return nil, ctx.Err()
+		},
+	}
+	_, err := checkout(m.BindContext(ctx), "+393331234567", 350)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("mock: got %v, want context.Canceled", err)
+	}
+	calls := m.Calls()
+	if len(calls) != 2 || calls[0].Method != "BindContext" || calls[0].Args[0] != ctx {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	srv.AddUser("+393331234567")
+	p, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = checkout(p.BindContext(ctx), "+393331234567", 350)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("server: got %v, want context.Canceled", err)
+	}
+	if n := len(srv.Charges()); n != 0 {
+		t.Fatalf("got %d charges on the server, want 0", n)
+	}
+	//p itself is not bound
+	_, err = checkout(p, "+393331234567", 350)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,385 @@
+/*
+Package satisgotest provides a fake Satispay API to test the code using satisgo without the network.
+
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	u := srv.AddUser("+393331234567")
+	p, err := srv.Client()
+
+A Cassette records the calls made to the staging API in a file, to replay them offline in the tests.
+
+*/
+package satisgotest
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+const (
+	authPath    = "/wally-services/protocol/authenticated"
+	usersPath   = "/online/v1/users"
+	chargesPath = "/online/v1/charges"
+	refundsPath = "/online/v1/refunds"
+	amountsPath = "/online/v1/amounts"
+	dateLayout  = "2006-01-02T15:04:05.000Z"
+)
+
+//Server is a fake Satispay API keeping users, charges and refunds in memory
+//It answers with the headers checked by the client (Digest, Content-Length, X-Satispay-Cid)
+//and a charge or a refund created again with the same Idempotency-Key is returned as it is
+type Server struct {
+	*httptest.Server
+	//Bearer is the only token accepted by the server
+	Bearer string
+
+	mu      sync.Mutex
+	users   []satisgo.User
+	charges []*satisgo.Charge
+	refunds []*satisgo.Refund
+	//idempotent are the charges created by Idempotency-Key
+	idempotent map[string]*satisgo.Charge
+	//idempotentRefunds are the refunds created by Idempotency-Key
+	idempotentRefunds map[string]*satisgo.Refund
+}
+
+//NewServer starts a fake Satispay API, Close it when done
+func NewServer() *Server {
+	s := &Server{
+		Bearer:            "satisgotest-token",
+		idempotent:        make(map[string]*satisgo.Charge),
+		idempotentRefunds: make(map[string]*satisgo.Refund),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+//Client generates a client for the fake server
+func (s *Server) Client(opts ...satisgo.Option) (*satisgo.Satis, error) {
+	opts = append([]satisgo.Option{satisgo.WithBaseURL(s.URL)}, opts...)
+	return satisgo.New(s.Bearer, "staging", opts...)
+}
+
+//AddUser registers a Satispay user with the phone number (E.164 format)
+func (s *Server) AddUser(phone string) satisgo.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := satisgo.User{ID: newID(), Phone: phone}
+	s.users = append(s.users, u)
+	return u
+}
+
+//SetChargeStatus simulates the user acting on the charge (ex. satisgo.Success)
+func (s *Server) SetChargeStatus(id, status, details string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.charge(id)
+	if c == nil {
+		return false
+	}
+	c.Status = status
+	c.StatusDetails = details
+	if status == satisgo.Success {
+		c.Paid = true
+		c.ChargeDate = time.Now().UTC().Format(dateLayout)
+	}
+	return true
+}
+
+//Charges returns a copy of the charges created on the server
+func (s *Server) Charges() []satisgo.Charge {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]satisgo.Charge, 0, len(s.charges))
+	for _, c := range s.charges {
+		list = append(list, *c)
+	}
+	return list
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+//write answers like the Satispay API does
+func write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("X-Satispay-Cid", newID())
+	if status == http.StatusNoContent {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(status)
+		return
+	}
+	body, _ := json.Marshal(v)
+	hash := sha512.Sum512(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("Digest", "SHA-512="+base64.StdEncoding.EncodeToString(hash[:]))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	write(w, status, map[string]interface{}{"code": code, "message": msg})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.Bearer {
+		writeError(w, http.StatusUnauthorized, 34, "Unauthorized")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := r.URL.Path
+	switch {
+	case path == authPath && r.Method == http.MethodGet:
+		write(w, http.StatusNoContent, nil)
+	case path == usersPath && r.Method == http.MethodPost:
+		s.lookupUser(w, r)
+	case path == usersPath && r.Method == http.MethodGet:
+		list := make([]interface{}, 0, len(s.users))
+		for i := range s.users {
+			list = append(list, &s.users[i])
+		}
+		s.list(w, r, list, func(i int) string { return s.users[i].ID })
+	case strings.HasPrefix(path, usersPath+"/") && r.Method == http.MethodGet:
+		id := strings.TrimPrefix(path, usersPath+"/")
+		for _, u := range s.users {
+			if u.ID == id {
+				write(w, http.StatusOK, u)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, 41, "User not found")
+	case path == chargesPath && r.Method == http.MethodPost:
+		s.createCharge(w, r)
+	case path == chargesPath && r.Method == http.MethodGet:
+		list := make([]interface{}, 0, len(s.charges))
+		for _, c := range s.charges {
+			list = append(list, c)
+		}
+		s.list(w, r, list, func(i int) string { return s.charges[i].ID })
+	case strings.HasPrefix(path, chargesPath+"/"):
+		c := s.charge(strings.TrimPrefix(path, chargesPath+"/"))
+		if c == nil {
+			writeError(w, http.StatusNotFound, 41, "Charge not found")
+			return
+		}
+		if r.Method == http.MethodPut {
+			s.updateCharge(w, r, c)
+			return
+		}
+		write(w, http.StatusOK, c)
+	case path == refundsPath && r.Method == http.MethodPost:
+		s.createRefund(w, r)
+	case path == refundsPath && r.Method == http.MethodGet:
+		chargeID := r.URL.Query().Get("charge_id")
+		list := make([]interface{}, 0, len(s.refunds))
+		ids := make([]string, 0, len(s.refunds))
+		for _, rf := range s.refunds {
+			if chargeID == "" || rf.ChargeID == chargeID {
+				list = append(list, rf)
+				ids = append(ids, rf.ID)
+			}
+		}
+		s.list(w, r, list, func(i int) string { return ids[i] })
+	case strings.HasPrefix(path, refundsPath+"/"):
+		id := strings.TrimPrefix(path, refundsPath+"/")
+		for _, rf := range s.refunds {
+			if rf.ID != id {
+				continue
+			}
+			if r.Method == http.MethodPut {
+				var body struct {
+					Metadata map[string]string `json:"metadata"`
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+				rf.Metadata = body.Metadata
+			}
+			write(w, http.StatusOK, rf)
+			return
+		}
+		writeError(w, http.StatusNotFound, 41, "Refund not found")
+	case path == amountsPath && r.Method == http.MethodGet:
+		s.amounts(w, r)
+	default:
+		writeError(w, http.StatusNotFound, 41, "Not found")
+	}
+}
+
+func (s *Server) charge(id string) *satisgo.Charge {
+	for _, c := range s.charges {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Server) lookupUser(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Phone string `json:"phone_number"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 36, "Invalid body")
+		return
+	}
+	for _, u := range s.users {
+		if u.Phone == body.Phone {
+			write(w, http.StatusOK, map[string]string{"id": u.ID, "uuid": u.ID, "phone_number": u.Phone})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, 41, "User not found")
+}
+
+func (s *Server) createCharge(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Idempotency-Key")
+	if c, ok := s.idempotent[key]; ok {
+		write(w, http.StatusOK, c)
+		return
+	}
+	c := new(satisgo.Charge)
+	err := json.NewDecoder(r.Body).Decode(c)
+	if err != nil || c.UserID == "" || c.Amount == 0 {
+		writeError(w, http.StatusBadRequest, 36, "Invalid charge")
+		return
+	}
+	c.ID = newID()
+	c.Status = satisgo.Required
+	expire := 15 * time.Minute
+	if c.ExpireIn > 0 {
+		expire = time.Duration(c.ExpireIn) * time.Second
+	}
+	c.ExpireDate = time.Now().Add(expire).UTC().Format(dateLayout)
+	s.charges = append(s.charges, c)
+	if key != "" {
+		s.idempotent[key] = c
+	}
+	write(w, http.StatusOK, c)
+}
+
+func (s *Server) updateCharge(w http.ResponseWriter, r *http.Request, c *satisgo.Charge) {
+	var body struct {
+		State       string            `json:"charge_state"`
+		Description *string           `json:"description"`
+		Metadata    map[string]string `json:"metadata"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 36, "Invalid body")
+		return
+	}
+	if body.State == satisgo.Canceled {
+		if c.Status != satisgo.Required {
+			writeError(w, http.StatusBadRequest, 36, "Charge cannot be canceled")
+			return
+		}
+		c.Status = satisgo.Failure
+		c.StatusDetails = satisgo.Canceled
+	}
+	if body.Description != nil {
+		c.Description = *body.Description
+	}
+	if body.Metadata != nil {
+		c.Metadata = body.Metadata
+	}
+	write(w, http.StatusOK, c)
+}
+
+func (s *Server) createRefund(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Idempotency-Key")
+	if rf, ok := s.idempotentRefunds[key]; ok {
+		write(w, http.StatusOK, rf)
+		return
+	}
+	rf := new(satisgo.Refund)
+	err := json.NewDecoder(r.Body).Decode(rf)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 36, "Invalid refund")
+		return
+	}
+	c := s.charge(rf.ChargeID)
+	if c == nil || c.Status != satisgo.Success || rf.Amount == 0 || c.Refund+rf.Amount > c.Amount {
+		writeError(w, http.StatusBadRequest, 36, "Charge cannot be refunded")
+		return
+	}
+	c.Refund += rf.Amount
+	rf.ID = newID()
+	rf.Created = time.Now().UTC().Format(dateLayout)
+	s.refunds = append(s.refunds, rf)
+	if key != "" {
+		s.idempotentRefunds[key] = rf
+	}
+	write(w, http.StatusOK, rf)
+}
+
+//list pages the items with the limit and starting_after parameters
+func (s *Server) list(w http.ResponseWriter, r *http.Request, items []interface{}, id func(int) string) {
+	q := r.URL.Query()
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	start := 0
+	if after := q.Get("starting_after"); after != "" {
+		for i := range items {
+			if id(i) == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	write(w, http.StatusOK, map[string]interface{}{
+		"list":     items[start:end],
+		"has_more": end < len(items),
+	})
+}
+
+func (s *Server) amounts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err1 := strconv.ParseInt(q.Get("starting_date"), 10, 64)
+	to, err2 := strconv.ParseInt(q.Get("ending_date"), 10, 64)
+	if err1 != nil || err2 != nil {
+		writeError(w, http.StatusBadRequest, 36, "Invalid dates")
+		return
+	}
+	in := func(s string) bool {
+		t, err := time.Parse(dateLayout, s)
+		if err != nil {
+			return false
+		}
+		ms := t.UnixNano() / int64(time.Millisecond)
+		return ms >= from && ms < to
+	}
+	total := satisgo.Ammount{Currency: "EUR"}
+	for _, c := range s.charges {
+		if c.Status == satisgo.Success && in(c.ChargeDate) {
+			total.TotalCharge += int(c.Amount)
+		}
+	}
+	for _, rf := range s.refunds {
+		if in(rf.Created) {
+			total.TotalRefund += int(rf.Amount)
+		}
+	}
+	write(w, http.StatusOK, total)
+}
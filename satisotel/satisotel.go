@@ -0,0 +1,95 @@
+/*
+Package satisotel traces the operations of a satisgo client with OpenTelemetry.
+
+	p, err := satisgo.New(bearer, "production", satisgo.WithTracer(satisotel.New(nil)))
+	...
+	c, err := p.WithContext(ctx).Charges.Get(id)
+
+Personal data (phone numbers, user ids) is not recorded unless WithPII is given.
+
+*/
+package satisotel
+
+import (
+	"context"
+
+	"github.com/drymonsoon/satisgo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//InstrumentationName is the name of the OpenTelemetry tracer
+const InstrumentationName = "github.com/drymonsoon/satisgo"
+
+//Tracer is a satisgo.Tracer producing OpenTelemetry spans
+type Tracer struct {
+	tracer trace.Tracer
+	pii    bool
+}
+
+//Option configures the Tracer
+type Option func(*Tracer)
+
+//WithPII records the attributes marked as personal data (phone numbers, user ids)
+func WithPII() Option {
+	return func(t *Tracer) {
+		t.pii = true
+	}
+}
+
+//New generates a Tracer from tp, the global TracerProvider is used when tp is nil
+func New(tp trace.TracerProvider, opts ...Option) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	t := &Tracer{tracer: tp.Tracer(InstrumentationName)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+//Start implements satisgo.Tracer
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, satisgo.Span) {
+	kind := trace.SpanKindInternal
+	if name == "satisgo.call" {
+		kind = trace.SpanKindClient
+	}
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(kind))
+	return ctx, &span{s: s, pii: t.pii}
+}
+
+type span struct {
+	s   trace.Span
+	pii bool
+}
+
+//SetAttributes implements satisgo.Span
+func (sp *span) SetAttributes(attrs ...satisgo.Attribute) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		if a.PII && !sp.pii {
+			continue
+		}
+		switch v := a.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(a.Key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(a.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.Key, v))
+		}
+	}
+	sp.s.SetAttributes(kvs...)
+}
+
+//End implements satisgo.Span
+func (sp *span) End(err error) {
+	if err != nil {
+		sp.s.RecordError(err)
+		sp.s.SetStatus(codes.Error, err.Error())
+	}
+	sp.s.End()
+}
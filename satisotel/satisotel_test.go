@@ -0,0 +1,149 @@
+package satisotel_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+	"github.com/drymonsoon/satisgo/satisotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+//provider records the spans started by its tracers, the rest is a no-op
+type provider struct {
+	noop.TracerProvider
+	mu    sync.Mutex
+	spans []*span
+}
+
+type tracer struct {
+	noop.Tracer
+	p *provider
+}
+
+type span struct {
+	noop.Span
+	name   string
+	kind   trace.SpanKind
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	msg    string
+	errs   []error
+	ended  bool
+}
+
+func (p *provider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &tracer{p: p}
+}
+
+func (t *tracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	s := &span{name: name, kind: cfg.SpanKind(), attrs: make(map[attribute.Key]attribute.Value)}
+	t.p.mu.Lock()
+	t.p.spans = append(t.p.spans, s)
+	t.p.mu.Unlock()
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+func (s *span) SetAttributes(kvs ...attribute.KeyValue) {
+	for _, kv := range kvs {
+		s.attrs[kv.Key] = kv.Value
+	}
+}
+
+func (s *span) RecordError(err error, opts ...trace.EventOption) { s.errs = append(s.errs, err) }
+func (s *span) SetStatus(code codes.Code, msg string)           { s.status, s.msg = code, msg }
+func (s *span) End(opts ...trace.SpanEndOption)                 { s.ended = true }
+
+func (p *provider) named(name string) *span {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.spans {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func run(t *testing.T, opts ...satisotel.Option) *provider {
+	t.Helper()
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddUser("+393331234567")
+	tp := new(provider)
+	p, err := srv.Client(satisgo.WithTracer(satisotel.New(tp, opts...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.UserFromPhone("+393331234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.GetCharge("not-on-satispay")
+	if !satisgo.IsNotFound(err) {
+		t.Fatalf("got %v, want a not found error", err)
+	}
+	return tp
+}
+
+func TestTracer(t *testing.T) {
+	tp := run(t)
+	lookup := tp.named("satisgo.UserFromPhone")
+	if lookup == nil || !lookup.ended || lookup.status == codes.Error || lookup.kind != trace.SpanKindInternal {
+		t.Fatalf("unexpected UserFromPhone span %+v", lookup)
+	}
+	if _, ok := lookup.attrs[satisgo.AttrPhone]; ok {
+		t.Fatal("the phone number should not be recorded without WithPII")
+	}
+	call := tp.named("satisgo.call")
+	if call == nil || call.kind != trace.SpanKindClient || call.attrs[satisgo.AttrStatusCode].AsInt64() != 200 {
+		t.Fatalf("unexpected call span %+v", call)
+	}
+	get := tp.named("satisgo.GetCharge")
+	if get == nil || get.status != codes.Error || len(get.errs) != 1 || !satisgo.IsNotFound(get.errs[0]) {
+		t.Fatalf("unexpected GetCharge span %+v", get)
+	}
+	var apiErr *satisgo.APIError
+	if !errors.As(get.errs[0], &apiErr) {
+		t.Fatalf("the recorded error should be the API error, got %T", get.errs[0])
+	}
+}
+
+func TestTracerPII(t *testing.T) {
+	tp := run(t, satisotel.WithPII())
+	lookup := tp.named("satisgo.UserFromPhone")
+	if lookup.attrs[satisgo.AttrPhone].AsString() != "+393331234567" {
+		t.Fatalf("the phone number should be recorded with WithPII, got %v", lookup.attrs)
+	}
+}
+
+func TestTracerInvalidPhone(t *testing.T) {
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	tp := new(provider)
+	p, err := srv.Client(satisgo.WithTracer(satisotel.New(tp)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.UserFromPhone("+39 333 1234567 890123")
+	if !errors.Is(err, satisgo.ErrInvalidPhone) {
+		t.Fatalf("got %v, want ErrInvalidPhone", err)
+	}
+	lookup := tp.named("satisgo.UserFromPhone")
+	if lookup == nil || lookup.status != codes.Error || len(lookup.errs) != 1 {
+		t.Fatalf("unexpected UserFromPhone span %+v", lookup)
+	}
+	for _, s := range []string{lookup.msg, lookup.errs[0].Error()} {
+		if strings.Contains(strings.ReplaceAll(s, " ", ""), "3331234567") {
+			t.Fatalf("the phone number should not be in the span: %q", s)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Package satisprom exposes the activity of a satisgo client as Prometheus metrics.
+
+	c := satisprom.New("shop")
+	prometheus.MustRegister(c)
+	p, err := satisgo.New(bearer, "production", satisgo.WithObserver(c))
+
+*/
+package satisprom
+
+import (
+	"strconv"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Collector is a satisgo.CacheObserver and a prometheus.Collector
+type Collector struct {
+	calls     *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	integrity *prometheus.CounterVec
+	retries   *prometheus.CounterVec
+	charges   *prometheus.CounterVec
+	refunded  prometheus.Counter
+	cache     *prometheus.CounterVec
+}
+
+//New generates a Collector, every metric name starts with namespace_satisgo_
+func New(namespace string) *Collector {
+	return &Collector{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "requests_total",
+			Help:      "Calls made to the Satispay API by endpoint, method and status code (0 when no response).",
+		}, []string{"endpoint", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the calls made to the Satispay API.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2, 3, 5},
+		}, []string{"endpoint", "method"}),
+		integrity: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "integrity_failures_total",
+			Help:      "Responses of the Satispay API that did not pass the integrity checks.",
+		}, []string{"endpoint"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "retries_total",
+			Help:      "Calls to the Satispay API that have been retried.",
+		}, []string{"endpoint"}),
+		charges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "charges_total",
+			Help:      "Charges created and seen reaching a final status, with the status details.",
+		}, []string{"status", "details"}),
+		refunded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "refunded_cents_total",
+			Help:      "EuroCents refunded through the client.",
+		}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "satisgo",
+			Name:      "user_cache_lookups_total",
+			Help:      "Lookups in the user cache by result (hit, negative_hit, miss).",
+		}, []string{"result"}),
+	}
+}
+
+//ObserveCall implements satisgo.Observer
+func (c *Collector) ObserveCall(s satisgo.CallStats) {
+	c.calls.WithLabelValues(s.Endpoint, s.Method, strconv.Itoa(s.StatusCode)).Inc()
+	c.latency.WithLabelValues(s.Endpoint, s.Method).Observe(s.Duration.Seconds())
+	if s.IntegrityFailure {
+		c.integrity.WithLabelValues(s.Endpoint).Inc()
+	}
+	if s.Retries > 0 {
+		c.retries.WithLabelValues(s.Endpoint).Add(float64(s.Retries))
+	}
+}
+
+//ObserveChargeEvent implements satisgo.Observer
+func (c *Collector) ObserveChargeEvent(e satisgo.ChargeEvent) {
+	c.charges.WithLabelValues(e.To, e.Details).Inc()
+}
+
+//ObserveRefund implements satisgo.Observer
+func (c *Collector) ObserveRefund(r satisgo.Refund) {
+	c.refunded.Add(float64(r.Amount))
+}
+
+//ObserveUserCache implements satisgo.CacheObserver
+func (c *Collector) ObserveUserCache(result string) {
+	c.cache.WithLabelValues(result).Inc()
+}
+
+//Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.calls.Describe(ch)
+	c.latency.Describe(ch)
+	c.integrity.Describe(ch)
+	c.retries.Describe(ch)
+	c.charges.Describe(ch)
+	c.refunded.Describe(ch)
+	c.cache.Describe(ch)
+}
+
+//Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.calls.Collect(ch)
+	c.latency.Collect(ch)
+	c.integrity.Collect(ch)
+	c.retries.Collect(ch)
+	c.charges.Collect(ch)
+	c.refunded.Collect(ch)
+	c.cache.Collect(ch)
+}
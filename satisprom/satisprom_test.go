@@ -0,0 +1,66 @@
+package satisprom_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+	"github.com/drymonsoon/satisgo/satisprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := satisprom.New("shop")
+	reg := prometheus.NewPedanticRegistry()
+	err := reg.Register(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := satisgotest.NewServer()
+	defer srv.Close()
+	u := srv.AddUser("+393331234567")
+	p, err := srv.Client(satisgo.WithObserver(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := p.Charges.Create(satisgo.ChargeCreateParams{UserID: u.ID, Amount: 500, CallbackURL: "https://example.com/callback?charge_id={uuid}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetChargeStatus(ch.ID, satisgo.Success, "")
+	_, err = p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: ch.ID, Amount: 150})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := testutil.GatherAndLint(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pr := range problems {
+		t.Errorf("lint %s: %s", pr.Metric, pr.Text)
+	}
+	//POST charges, GET charges (read by Refunds.Create), POST refunds
+	if n := testutil.CollectAndCount(c, "shop_satisgo_requests_total"); n != 3 {
+		t.Fatalf("got %d series of requests, want 3", n)
+	}
+	if n := testutil.CollectAndCount(c, "shop_satisgo_request_duration_seconds"); n != 3 {
+		t.Fatalf("got %d series of latency, want 3", n)
+	}
+	if n := testutil.CollectAndCount(c, "shop_satisgo_integrity_failures_total"); n != 0 {
+		t.Fatalf("got %d series of integrity failures, want none", n)
+	}
+	if n := testutil.CollectAndCount(c, "shop_satisgo_charges_total"); n != 1 {
+		t.Fatalf("got %d series of charges, want 1", n)
+	}
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP shop_satisgo_refunded_cents_total EuroCents refunded through the client.
+# TYPE shop_satisgo_refunded_cents_total counter
+shop_satisgo_refunded_cents_total 150
+`), "shop_satisgo_refunded_cents_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
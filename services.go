@@ -0,0 +1,317 @@
+package satisgo
+
+import (
+	"fmt"
+	"time"
+)
+
+//UserService groups the operations on the users, use it as p.Users
+type UserService struct {
+	p *Satis
+}
+
+//ChargeService groups the operations on the charges, use it as p.Charges
+type ChargeService struct {
+	p *Satis
+}
+
+//RefundService groups the operations on the refunds, use it as p.Refunds
+type RefundService struct {
+	p *Satis
+}
+
+//AmountService groups the totals of charges and refunds, use it as p.Amounts
+type AmountService struct {
+	p *Satis
+}
+
+//bindServices points the services to p, it is called again on every copy of the client
+func (p *Satis) bindServices() {
+	p.Users = &UserService{p}
+	p.Charges = &ChargeService{p}
+	p.Refunds = &RefundService{p}
+	p.Amounts = &AmountService{p}
+}
+
+//List returns all the users
+func (s *UserService) List() ([]User, error) {
+	return s.p.allUsers()
+}
+
+//ChargeCreateParams are the fields of a new charge
+type ChargeCreateParams struct {
+	//UserID is the user to charge (see p.Users.Lookup), mandatory
+	UserID string
+	//Amount is expressed in EuroCents, mandatory
+	Amount uint64
+	//CallbackURL is called when the status of the charge changes, mandatory
+	CallbackURL string
+	Description string
+	//Expire is the time the user has to accept the charge, 15 minutes when zero
+	Expire time.Duration
+	//Metadata has max 20 fields
+	Metadata map[string]string
+	//IdempotencyKey makes the creation safe to retry, a random one is used when empty
+	IdempotencyKey string
+}
+
+//ChargeUpdateParams are the fields to change in a charge, the nil ones are left as they are
+type ChargeUpdateParams struct {
+	//Description cannot be changed once the charge has failed or has been canceled
+	Description *string
+	//Metadata replaces the metadata of the charge
+	Metadata map[string]string
+}
+
+//ChargeListParams selects the charges to list, the empty fields do not filter
+type ChargeListParams struct {
+	//MetadataKey and MetadataValue select the charges tagged with the value, all the charges are scanned
+	//(the matches are added to the index of WithChargeIndex, see FindChargesByMetadata for a faster best-effort lookup)
+	MetadataKey   string
+	MetadataValue string
+	//Status is REQUIRED, SUCCESS or FAILURE
+	Status string
+}
+
+//Create makes a new charge, the user is notified in the app to accept it
+func (s *ChargeService) Create(params ChargeCreateParams) (*Charge, error) {
+	if params.Amount >= 1000000 {
+		return nil, fmt.Errorf("ammount to charge is too big: not supported by Satispay")
+	}
+	if params.UserID == "" {
+		return nil, fmt.Errorf("not possible to create a charge if user_id is empty")
+	}
+	c := &Charge{UserID: params.UserID, Currency: eur, EmailOnSuccess: true}
+	c.Amount = params.Amount
+	c.CallbackURL = params.CallbackURL
+	c.Description = params.Description
+	if params.Expire != 0 {
+		err := c.SetExpiration(params.Expire)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range params.Metadata {
+		err := c.SetMetadata(k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p := s.p
+	if params.IdempotencyKey != "" {
+		p = p.WithContext(ContextWithIdempotencyKey(p.context(), params.IdempotencyKey))
+	}
+	err := p.Charges.create(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//Cancel cancels a charge not yet accepted by the user, it is read first to check its status
+func (s *ChargeService) Cancel(id string) (*Charge, error) {
+	c, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.cancel(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//Update changes the description and the metadata of a charge in a single PUT, it is read first to check its status
+func (s *ChargeService) Update(id string, params ChargeUpdateParams) (*Charge, error) {
+	if params.Description == nil && params.Metadata == nil {
+		return nil, fmt.Errorf("nothing to update in charge %s", id)
+	}
+	if err := validateMetadata(params.Metadata); err != nil {
+		return nil, err
+	}
+	c, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.update(c, params)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//List returns the charges selected by params, all of them when params is nil
+func (s *ChargeService) List(params *ChargeListParams) ([]Charge, error) {
+	if params == nil {
+		params = new(ChargeListParams)
+	}
+	all, err := s.p.allCharges()
+	if err != nil {
+		return nil, err
+	}
+	//the index may not know every charge: the list is always a full scan
+	selected := all[:0]
+	for i := range all {
+		c := &all[i]
+		if params.MetadataKey != "" {
+			if v, ok := c.Metadata[params.MetadataKey]; !ok || v != params.MetadataValue {
+				continue
+			}
+			s.p.indexCharge(nil, c)
+		}
+		if params.Status != "" && c.Status != params.Status {
+			continue
+		}
+		selected = append(selected, *c)
+	}
+	return selected, nil
+}
+
+//RefundCreateParams are the fields of a new refund
+type RefundCreateParams struct {
+	//ChargeID is the charge to refund, mandatory
+	ChargeID string
+	//Amount is expressed in EuroCents, mandatory unless Full is set
+	Amount uint64
+	//Full refunds what is still refundable of the charge, Amount must be zero
+	Full bool
+	//Reason is ReasonDuplicate, ReasonFraud or ReasonCustomerRequest
+	Reason      string
+	Description string
+	//Metadata has max 20 fields
+	Metadata map[string]string
+	//IdempotencyKey makes the creation safe to retry, a random one is used when empty
+	IdempotencyKey string
+}
+
+//RefundUpdateParams are the fields to change in a refund
+type RefundUpdateParams struct {
+	//Metadata replaces the metadata of the refund
+	Metadata map[string]string
+}
+
+//RefundListParams selects the refunds to list, the empty fields do not filter
+type RefundListParams struct {
+	//ChargeID lists only the refunds of a charge
+	ChargeID string
+}
+
+//Create refunds a charge, which is read first to check its status and what is still refundable
+func (s *RefundService) Create(params RefundCreateParams) (*Refund, error) {
+	if params.ChargeID == "" {
+		return nil, fmt.Errorf("Charge ID cannot be empty")
+	}
+	if params.Full == (params.Amount != 0) {
+		return nil, fmt.Errorf("Set either Amount or Full to refund charge %s", params.ChargeID)
+	}
+	c, err := s.p.Charges.Get(params.ChargeID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.CanRefund() {
+		return nil, &StateError{ChargeID: c.ID, Status: c.Status, Op: "refund"}
+	}
+	r := &Refund{ChargeID: c.ID, Currency: eur}
+	r.Amount = params.Amount
+	if params.Full {
+		r.Amount = c.Refundable()
+		if r.Amount == 0 {
+			return nil, fmt.Errorf("Charge %s has nothing left to refund", c.ID)
+		}
+	}
+	if r.Amount > c.Refundable() {
+		return nil, fmt.Errorf("ammount to refund is bigger than the refundable ammount of the charge (%d cents)", c.Refundable())
+	}
+	if params.Reason != "" {
+		err = r.SetReason(params.Reason)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.Description = params.Description
+	for k, v := range params.Metadata {
+		err = r.SetMetadata(k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p := s.p
+	if params.IdempotencyKey != "" {
+		p = p.WithContext(ContextWithIdempotencyKey(p.context(), params.IdempotencyKey))
+	}
+	err = p.Refunds.create(r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//List returns the refunds selected by params, all of them when params is nil
+func (s *RefundService) List(params *RefundListParams) ([]Refund, error) {
+	if params == nil {
+		params = new(RefundListParams)
+	}
+	return s.p.allRefunds(params.ChargeID)
+}
+
+//Today returns the totals since midnight
+func (s *AmountService) Today() (*Ammount, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return s.p.getAmmount(today, now)
+}
+
+//Yesterday returns the totals of the day before
+func (s *AmountService) Yesterday() (*Ammount, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return s.p.getAmmount(today.Add(time.Duration(-24)*time.Hour), today)
+}
+
+//Date returns the totals of a day
+func (s *AmountService) Date(year, month, day int) (*Ammount, error) {
+	prec := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Now().Location())
+	return s.p.getAmmount(prec, prec.Add(24*time.Hour))
+}
+
+//ThisWeek returns the totals of the past 7 days
+func (s *AmountService) ThisWeek() (*Ammount, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
+	return s.p.getAmmount(today.Add(time.Duration(-167)*time.Hour), today)
+}
+
+//ThisMonth returns the totals since the first of the month
+func (s *AmountService) ThisMonth() (*Ammount, error) {
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
+	return s.p.getLongAmmount(month, today)
+}
+
+//ThisYear returns the totals since the first of january
+func (s *AmountService) ThisYear() (*Ammount, error) {
+	now := time.Now()
+	prec := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	last := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
+	return s.p.getLongAmmount(prec, last)
+}
+
+//Year returns the totals of a year, until today for the current one
+func (s *AmountService) Year(year int) (*Ammount, error) {
+	now := time.Now()
+	prec := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+	last := time.Date(year+1, time.January, 1, 0, 0, 0, 0, now.Location())
+	if year == now.Year() {
+		last = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	return s.p.getLongAmmount(prec, last)
+}
+
+//Range returns the totals between from and to, split in as many calls as needed
+func (s *AmountService) Range(from, to time.Time) (*Ammount, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("Interval is empty: from must be before to")
+	}
+	return s.p.getLongAmmount(from, to)
+}
@@ -0,0 +1,121 @@
+package satisgo_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestRefundCreateAmount(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+
+	for _, params := range []satisgo.RefundCreateParams{
+		{ChargeID: c.ID},
+		{ChargeID: c.ID, Amount: 100, Full: true},
+	} {
+		if _, err := p.Refunds.Create(params); err == nil {
+			t.Fatalf("%+v should be refused", params)
+		}
+	}
+	r, err := p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: c.ID, Amount: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Amount != 200 {
+		t.Fatalf("refunded %d, want 200", r.Amount)
+	}
+	r, err = p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: c.ID, Full: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Amount != 300 {
+		t.Fatalf("refunded %d, want what was left: 300", r.Amount)
+	}
+	if _, err = p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: c.ID, Full: true}); err == nil {
+		t.Fatal("a charge refunded in full should be refused")
+	}
+}
+
+func TestChargeUpdate(t *testing.T) {
+	var calls atomic.Int32
+	srv, p, u := newTestServer(t, satisgo.WithMiddleware(counter(&calls)))
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, map[string]string{"order_id": "A"})
+
+	calls.Store(0)
+	desc := "coffee"
+	c, err := p.Charges.Update(c.ID, satisgo.ChargeUpdateParams{Description: &desc, Metadata: map[string]string{"order_id": "B"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("got %d calls, want a GET and a single PUT", n)
+	}
+	if c.Description != desc || c.Metadata["order_id"] != "B" {
+		t.Fatalf("unexpected charge %+v", c)
+	}
+
+	failed := newTestCharge(t, srv, p, u, 500, satisgo.Failure, nil)
+	calls.Store(0)
+	_, err = p.Charges.Update(failed.ID, satisgo.ChargeUpdateParams{Description: &desc, Metadata: map[string]string{"order_id": "C"}})
+	var serr *satisgo.StateError
+	if !errors.As(err, &serr) {
+		t.Fatalf("got %v, want a StateError", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("got %d calls, want only the GET", n)
+	}
+}
+
+//amountDates records the first starting_date and the last ending_date asked to the amounts endpoint
+func amountDates(from, to *time.Time) satisgo.Middleware {
+	parse := func(ms string) time.Time {
+		n, _ := strconv.ParseInt(ms, 10, 64)
+		return time.UnixMilli(n)
+	}
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			q := req.URL.Query()
+			if q.Get("starting_date") != "" {
+				if from.IsZero() {
+					*from = parse(q.Get("starting_date"))
+				}
+				*to = parse(q.Get("ending_date"))
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+func TestAmountRanges(t *testing.T) {
+	var from, to time.Time
+	_, p, _ := newTestServer(t, satisgo.WithMiddleware(amountDates(&from, &to)))
+	now := time.Now()
+	for _, tc := range []struct {
+		name     string
+		call     func() (*satisgo.Ammount, error)
+		from, to time.Time
+	}{
+		{"ThisMonth", p.Amounts.ThisMonth, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), time.Time{}},
+		{"ThisYear", p.Amounts.ThisYear, time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location()), time.Time{}},
+		{"Year", func() (*satisgo.Ammount, error) { return p.Amounts.Year(2020) },
+			time.Date(2020, time.January, 1, 0, 0, 0, 0, now.Location()), time.Date(2021, time.January, 1, 0, 0, 0, 0, now.Location())},
+	} {
+		from, to = time.Time{}, time.Time{}
+		_, err := tc.call()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !from.Equal(tc.from) {
+			t.Errorf("%s starts at %v, want %v", tc.name, from, tc.from)
+		}
+		if !tc.to.IsZero() && !to.Equal(tc.to) {
+			t.Errorf("%s ends at %v, want %v", tc.name, to, tc.to)
+		}
+	}
+}
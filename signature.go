@@ -0,0 +1,206 @@
+package satisgo
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//SignatureVerifier verifies the HTTP Signature header (rsa-sha256) of the responses and of the
+//callback notifications sent by Satispay. Only the keys added to it are trusted (key pinning),
+//keys can be added and removed while it is in use to rotate them
+type SignatureVerifier struct {
+	//RequireSigned rejects the responses and notifications without a Signature header
+	RequireSigned bool
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+}
+
+//NewSignatureVerifier returns a verifier trusting no key, add the Satispay public key with AddKey
+func NewSignatureVerifier(requireSigned bool) *SignatureVerifier {
+	return &SignatureVerifier{RequireSigned: requireSigned, keys: make(map[string]*rsa.PublicKey)}
+}
+
+//AddKey trusts the RSA public key for the signatures with the keyId, pemKey is a PEM block
+//("PUBLIC KEY" or "RSA PUBLIC KEY"). Adding a keyId again replaces its key
+func (v *SignatureVerifier) AddKey(keyID string, pemKey []byte) error {
+	if keyID == "" {
+		return fmt.Errorf("keyId cannot be empty")
+	}
+	key, err := ParsePublicKey(pemKey)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.keys[keyID] = key
+	v.mu.Unlock()
+	return nil
+}
+
+//RemoveKey stops trusting the key with the keyId (ex. once a rotation is over)
+func (v *SignatureVerifier) RemoveKey(keyID string) {
+	v.mu.Lock()
+	delete(v.keys, keyID)
+	v.mu.Unlock()
+}
+
+//ParsePublicKey reads an RSA public key from a PEM block
+func ParsePublicKey(pemKey []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, fmt.Errorf("No PEM block found in the key")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		k, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("The key is not an RSA public key")
+		}
+		return key, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("PEM block of type %s is not a public key", block.Type)
+}
+
+//WithSignatureVerifier checks the signature of every response of the API with v
+//Unless WithIntegrityPolicy is given, the Date header is required too and checked against MaxSkew
+func WithSignatureVerifier(v *SignatureVerifier) Option {
+	return func(p *Satis) error {
+		if v == nil {
+			return fmt.Errorf("SignatureVerifier cannot be nil")
+		}
+		p.signatures = v
+		return nil
+	}
+}
+
+//VerifyResponse checks the signature of a response of the API, the body is left readable
+func (v *SignatureVerifier) VerifyResponse(r *http.Response) error {
+	body, err := readBody(&r.Body)
+	if err != nil {
+		return err
+	}
+	return v.verify(r.Header, "", body)
+}
+
+//VerifyRequest checks the signature of a callback notification, the body is left readable
+func (v *SignatureVerifier) VerifyRequest(r *http.Request) error {
+	body, err := readBody(&r.Body)
+	if err != nil {
+		return err
+	}
+	target := strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+	return v.verify(r.Header, target, body)
+}
+
+func readBody(b *io.ReadCloser) ([]byte, error) {
+	if *b == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(*b)
+	(*b).Close()
+	if err != nil {
+		return nil, err
+	}
+	*b = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+//parseSignature splits the Signature header: keyId="...",algorithm="...",headers="...",signature="..."
+func parseSignature(s string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		i := strings.Index(part, "=")
+		if i < 0 {
+			continue
+		}
+		params[strings.TrimSpace(part[:i])] = strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+	}
+	return params
+}
+
+//verify checks the Signature header, target is the (request-target) of a request ("" for a response)
+func (v *SignatureVerifier) verify(h http.Header, target string, body []byte) error {
+	s := h.Get("Signature")
+	if s == "" {
+		if v.RequireSigned {
+			return integrityErr(CheckSignature, "value in header does not exist")
+		}
+		return nil
+	}
+	params := parseSignature(s)
+	alg := params["algorithm"]
+	if alg != "" && alg != "rsa-sha256" {
+		return integrityErr(CheckSignature, "algorithm %s is not supported", alg)
+	}
+	v.mu.RLock()
+	key, ok := v.keys[params["keyId"]]
+	v.mu.RUnlock()
+	if !ok {
+		return integrityErr(CheckSignature, "keyId %q is not trusted", params["keyId"])
+	}
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return integrityErr(CheckSignature, "value in header is malformed")
+	}
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	//the body is covered only through its digest, which must be signed and true
+	if len(body) > 0 {
+		if !contains(headers, "digest") {
+			return integrityErr(CheckSignature, "does not cover the Digest of the body")
+		}
+		err = (&IntegrityPolicy{Digest: IntegrityRequire}).checkDigest(h, body)
+		if err != nil {
+			return err
+		}
+	}
+	lines := make([]string, len(headers))
+	for i, name := range headers {
+		switch {
+		case name == "(request-target)" && target != "":
+			lines[i] = name + ": " + target
+		case name == "(request-target)":
+			return integrityErr(CheckSignature, "covers (request-target) in a response")
+		default:
+			values, ok := h[http.CanonicalHeaderKey(name)]
+			if !ok {
+				return integrityErr(CheckSignature, "covers the %s header which does not exist", name)
+			}
+			lines[i] = name + ": " + strings.Join(values, ", ")
+		}
+	}
+	hashed := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sig)
+	if err != nil {
+		return integrityErr(CheckSignature, "is not valid for keyId %q", params["keyId"])
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package satisgo_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//signer signs the responses of the API with key as Satispay does, edit changes the request before it is sent
+func signer(key *rsa.PrivateKey, keyID string, date time.Time, edit func(*http.Request)) satisgo.Middleware {
+	return func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if edit != nil {
+				edit(req)
+			}
+			resp, err := next.Do(req)
+			if err != nil {
+				return resp, err
+			}
+			resp.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+			lines := "date: " + resp.Header.Get("Date") + "\ndigest: " + resp.Header.Get("Digest")
+			hashed := sha256.Sum256([]byte(lines))
+			sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+			if err != nil {
+				return nil, err
+			}
+			resp.Header.Set("Signature", `keyId="`+keyID+`",algorithm="rsa-sha256",headers="date digest",signature="`+base64.StdEncoding.EncodeToString(sig)+`"`)
+			return resp, nil
+		})
+	}
+}
+
+func newVerifier(t *testing.T, keyID string, key *rsa.PrivateKey) *satisgo.SignatureVerifier {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := satisgo.NewSignatureVerifier(true)
+	err = v.AddKey(keyID, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, nil)
+	replayed := newTestCharge(t, srv, p, u, 100, satisgo.Success, nil)
+	v := newVerifier(t, "satispay", key)
+
+	cases := []struct {
+		name string
+		mw   satisgo.Middleware
+		//check is the failed check, empty when the call succeeds or fails for another reason
+		check string
+		fails bool
+	}{
+		{"signed", signer(key, "satispay", time.Now(), nil), "", false},
+		{"unsigned", func(next satisgo.Doer) satisgo.Doer { return next }, satisgo.CheckSignature, true},
+		{"untrusted key", signer(other, "satispay", time.Now(), nil), satisgo.CheckSignature, true},
+		{"unknown keyId", signer(key, "rotated", time.Now(), nil), satisgo.CheckSignature, true},
+		//the signature is fine, the Date is what tells it is old
+		{"old response", signer(key, "satispay", time.Now().Add(-time.Hour), nil), satisgo.CheckDate, true},
+		//a signed response of another charge
+		{"other charge", signer(key, "satispay", time.Now(), func(req *http.Request) {
+			req.URL.Path = strings.Replace(req.URL.Path, c.ID, replayed.ID, 1)
+		}), "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := srv.Client(satisgo.WithSignatureVerifier(v), satisgo.WithMiddleware(tc.mw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Charges.Get(c.ID)
+			if !tc.fails {
+				if err != nil || got.ID != c.ID {
+					t.Fatalf("got %+v, %v", got, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("got %+v, want an error", got)
+			}
+			var ie *satisgo.IntegrityError
+			if tc.check != "" && (!errors.As(err, &ie) || ie.Check != tc.check) {
+				t.Fatalf("got %v, want the %s check to fail", err, tc.check)
+			}
+			if tc.check == "" && !strings.Contains(err.Error(), replayed.ID) {
+				t.Fatalf("got %v, want the id of the response refused", err)
+			}
+		})
+	}
+}
+
+func TestSignatureVerifierExplicitPolicy(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, nil)
+	//the policy given by the caller is kept as it is
+	p, err = srv.Client(
+		satisgo.WithSignatureVerifier(newVerifier(t, "satispay", key)),
+		satisgo.WithIntegrityPolicy(satisgo.DefaultIntegrityPolicy),
+		satisgo.WithMiddleware(signer(key, "satispay", time.Now().Add(-time.Hour), nil)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Charges.Get(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetRefundID(t *testing.T) {
+	srv, p, u := newTestServer(t)
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Success, nil)
+	r1, err := p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: c.ID, Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := p.Refunds.Create(satisgo.RefundCreateParams{ChargeID: c.ID, Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	swap := func(next satisgo.Doer) satisgo.Doer {
+		return satisgo.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.URL.Path = strings.Replace(req.URL.Path, r1.ID, r2.ID, 1)
+			return next.Do(req)
+		})
+	}
+	p, err = srv.Client(satisgo.WithMiddleware(swap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Refunds.Get(r1.ID); err == nil {
+		t.Fatalf("got refund %s asking for %s", r.ID, r1.ID)
+	}
+}
@@ -0,0 +1,138 @@
+package satisgo
+
+import (
+	"fmt"
+	"time"
+)
+
+//chargeTransitions lists the statuses a charge can move to from each status
+//The empty status is a charge not yet created. Satispay reports a canceled charge as
+//Failure with StatusDetails Canceled, Canceled is accepted as a status for the callers using it as one
+var chargeTransitions = map[string][]string{
+	"":       {Required},
+	Required: {Success, Failure, Canceled},
+}
+
+//ChargeEvent is a change of status of a charge
+//A cancellation is an event To Failure with Details Canceled, as Satispay reports it
+type ChargeEvent struct {
+	ChargeID string `json:"charge_id"`
+	//From is empty when the charge has just been created
+	From string `json:"from"`
+	To   string `json:"to"`
+	//Details is the StatusDetails of the charge after the transition (ex. ErrExpired)
+	Details string    `json:"details,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+//StateError is returned when an operation is not allowed by the status of a charge
+type StateError struct {
+	ChargeID string
+	Status   string
+	Op       string
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("Charge %s is in status %q: %s not allowed", e.ChargeID, e.Status, e.Op)
+}
+
+//ValidTransition tells if a charge can go from a status to another
+func ValidTransition(from, to string) bool {
+	for _, s := range chargeTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+//IsTerminal is true when the status of the charge cannot change anymore
+//A canceled charge is terminal: its Status is Failure (StatusDetails Canceled), or Canceled when set by the caller
+func (c *Charge) IsTerminal() bool {
+	return c.Status == Success || c.Status == Failure || c.Status == Canceled
+}
+
+//CanCancel is true when the charge is waiting for the payment
+//Once canceled the Status of the charge is Failure with StatusDetails Canceled
+func (c *Charge) CanCancel() bool {
+	return c.ID != "" && c.Status == Required
+}
+
+//CanRefund is true when the charge has been paid and not completely refunded yet
+func (c *Charge) CanRefund() bool {
+	return c.ID != "" && c.Status == Success && c.Refund < c.Amount
+}
+
+//CanUpdateDescription is true when the charge has not failed or been canceled
+func (c *Charge) CanUpdateDescription() bool {
+	return c.ID != "" && (c.Status == Required || c.Status == Success)
+}
+
+//Refundable is the ammount in EuroCents that can still be refunded
+func (c *Charge) Refundable() uint64 {
+	if !c.CanRefund() {
+		return 0
+	}
+	return c.Amount - c.Refund
+}
+
+//Transition returns the event of the charge going from its status to the one of next
+//The event is nil when the status has not changed, an error is returned if the transition is not allowed
+func (c *Charge) Transition(next *Charge) (*ChargeEvent, error) {
+	if c.Status == next.Status {
+		return nil, nil
+	}
+	e := &ChargeEvent{
+		ChargeID: next.ID,
+		From:     c.Status,
+		To:       next.Status,
+		Details:  next.StatusDetails,
+		At:       time.Now(),
+	}
+	if !ValidTransition(c.Status, next.Status) {
+		return e, fmt.Errorf("Charge %s cannot go from %q to %q", next.ID, c.Status, next.Status)
+	}
+	return e, nil
+}
+
+//Refresh fetches the charge from the API and returns the transition that occurred, if any
+func (c *Charge) Refresh(p *Satis) (*ChargeEvent, error) {
+	if c.ID == "" {
+		return nil, fmt.Errorf("Charge ID cannot be empty")
+	}
+	ch, err := p.Charges.Get(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	e := p.transition(c, ch)
+	*c = *ch
+	return e, nil
+}
+
+//WithChargeEvents calls fn every time the client sees a charge changing status
+//(creation, cancellation, updates and Refresh)
+func WithChargeEvents(fn func(ChargeEvent)) Option {
+	return func(p *Satis) error {
+		if fn == nil {
+			return fmt.Errorf("charge events handler cannot be nil")
+		}
+		p.onEvent = fn
+		return nil
+	}
+}
+
+//transition emits the event of the charge going from old to next
+//Invalid transitions are emitted as well: the API is the source of truth
+func (p *Satis) transition(old, next *Charge) *ChargeEvent {
+	e, _ := old.Transition(next)
+	if e == nil {
+		return nil
+	}
+	if p.onEvent != nil {
+		p.onEvent(*e)
+	}
+	if p.observer != nil {
+		p.observer.ObserveChargeEvent(*e)
+	}
+	return e
+}
@@ -0,0 +1,65 @@
+package satisgo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+func TestValidTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		valid    bool
+	}{
+		{"", satisgo.Required, true},
+		{satisgo.Required, satisgo.Success, true},
+		{satisgo.Required, satisgo.Failure, true},
+		{satisgo.Required, satisgo.Canceled, true},
+		{satisgo.Canceled, satisgo.Required, false},
+		{satisgo.Success, satisgo.Required, false},
+		{satisgo.Failure, satisgo.Success, false},
+	}
+	for _, tt := range tests {
+		if got := satisgo.ValidTransition(tt.from, tt.to); got != tt.valid {
+			t.Errorf("ValidTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.valid)
+		}
+	}
+}
+
+func TestCancelTransition(t *testing.T) {
+	var events []satisgo.ChargeEvent
+	srv, p, u := newTestServer(t, satisgo.WithChargeEvents(func(e satisgo.ChargeEvent) { events = append(events, e) }))
+	c := newTestCharge(t, srv, p, u, 500, satisgo.Required, nil)
+	c, err := p.Charges.Cancel(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != satisgo.Failure || c.StatusDetails != satisgo.Canceled || !c.IsTerminal() {
+		t.Fatalf("unexpected canceled charge %+v", c)
+	}
+	last := events[len(events)-1]
+	if last.From != satisgo.Required || last.To != satisgo.Failure || last.Details != satisgo.Canceled {
+		t.Fatalf("unexpected event %+v", last)
+	}
+	_, err = p.Charges.Cancel(c.ID)
+	var se *satisgo.StateError
+	if !errors.As(err, &se) {
+		t.Fatalf("got %v, want a StateError", err)
+	}
+	if c.CanRefund() || c.CanUpdateDescription() {
+		t.Fatal("a canceled charge cannot be refunded or updated")
+	}
+}
+
+func TestCanceledStatus(t *testing.T) {
+	c := &satisgo.Charge{ID: "charge-id", Status: satisgo.Required}
+	e, err := c.Transition(&satisgo.Charge{ID: "charge-id", Status: satisgo.Canceled})
+	if err != nil || e == nil || e.To != satisgo.Canceled {
+		t.Fatalf("got %+v, %v", e, err)
+	}
+	c.Status = satisgo.Canceled
+	if !c.IsTerminal() || c.CanCancel() {
+		t.Fatal("a charge with status CANCELED is terminal")
+	}
+}
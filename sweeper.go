@@ -0,0 +1,258 @@
+package satisgo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	//SweepCanceled the charge has been canceled, by the sweeper or before it got to it
+	SweepCanceled = "CANCELED"
+	//SweepExpired the charge has been expired by Satispay before the sweeper got to it
+	SweepExpired = "EXPIRED"
+	//SweepPaid the charge has been paid, it is not tracked anymore
+	SweepPaid = "PAID"
+	//SweepFailed the charge failed for some other reason (see StatusDetails)
+	SweepFailed = "FAILED"
+	//SweepError the sweeper could not refresh or cancel the charge, it will try again
+	SweepError = "ERROR"
+)
+
+//SweepReport tells what happened to a tracked charge
+type SweepReport struct {
+	Charge  Charge
+	Outcome string
+	Err     error
+}
+
+//SweeperConfig configures a Sweeper
+type SweeperConfig struct {
+	//Interval between two sweeps (30 seconds by default)
+	Interval time.Duration
+	//OnReport is called for every charge the sweeper is done with (and for errors)
+	OnReport func(SweepReport)
+}
+
+type sweepEntry struct {
+	charge    Charge
+	deadline  time.Time
+	abandoned bool
+}
+
+//Sweeper cancels the charges still waiting for a payment when the order behind them is gone
+//Charges are tracked with Track (or created through the sweeper), they are canceled when their
+//deadline passes or Abandon is called, and reported when Satispay already closed them
+type Sweeper struct {
+	p        *Satis
+	interval time.Duration
+	onReport func(SweepReport)
+
+	mu      sync.Mutex
+	tracked map[string]*sweepEntry
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+//NewSweeper generates a Sweeper for the charges of the client, start it with Run
+func (p *Satis) NewSweeper(cfg SweeperConfig) *Sweeper {
+	s := &Sweeper{
+		p:        p,
+		interval: cfg.Interval,
+		onReport: cfg.OnReport,
+		tracked:  make(map[string]*sweepEntry),
+		wake:     make(chan struct{}, 1),
+	}
+	if s.interval <= 0 {
+		s.interval = 30 * time.Second
+	}
+	return s
+}
+
+//CreateCharge creates the charge and tracks it until deadline
+func (s *Sweeper) CreateCharge(c *Charge, deadline time.Time) error {
+	err := s.p.Charges.create(c)
+	if err != nil {
+		return err
+	}
+	return s.Track(c, deadline)
+}
+
+//Track makes the sweeper cancel the charge if it is still REQUIRED after deadline
+//A zero deadline only reports the charge once Satispay closes it
+func (s *Sweeper) Track(c *Charge, deadline time.Time) error {
+	if c.ID == "" {
+		return fmt.Errorf("Charge ID cannot be empty")
+	}
+	if c.IsTerminal() {
+		return &StateError{ChargeID: c.ID, Status: c.Status, Op: "track"}
+	}
+	s.mu.Lock()
+	s.tracked[c.ID] = &sweepEntry{charge: *c, deadline: deadline}
+	s.mu.Unlock()
+	return nil
+}
+
+//Abandon is the signal that the order behind the charge is gone: it is canceled at once
+func (s *Sweeper) Abandon(chargeID string) error {
+	s.mu.Lock()
+	e, ok := s.tracked[chargeID]
+	if ok {
+		e.abandoned = true
+	}
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("Charge %s is not tracked", chargeID)
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+//Untrack stops tracking the charge without touching it
+func (s *Sweeper) Untrack(chargeID string) {
+	s.mu.Lock()
+	delete(s.tracked, chargeID)
+	s.mu.Unlock()
+}
+
+//Pending returns the number of charges being tracked
+func (s *Sweeper) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tracked)
+}
+
+//Run sweeps every Interval (and as soon as a charge is abandoned) until ctx is done or Stop is called
+//The calls of the sweep in progress are bound to ctx: Stop lets the sweep complete, canceling ctx interrupts it
+func (s *Sweeper) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return fmt.Errorf("Sweeper is already running")
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	stop, done := s.stop, s.done
+	s.mu.Unlock()
+	//only Run resets running: a new Run cannot start before this one has returned
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+		close(done)
+	}()
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		case <-t.C:
+		case <-s.wake:
+		}
+		s.Sweep(ctx)
+	}
+}
+
+//Stop makes Run return and waits for the sweep in progress to complete
+func (s *Sweeper) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	stop, done := s.stop, s.done
+	//the first Stop closes the channel, the others only wait
+	s.stop = nil
+	s.mu.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+	<-done
+}
+
+//Sweep does a single pass over the charges that are due
+func (s *Sweeper) Sweep(ctx context.Context) {
+	now := time.Now()
+	due := make([]sweepEntry, 0)
+	s.mu.Lock()
+	for _, e := range s.tracked {
+		if e.abandoned || (!e.deadline.IsZero() && !now.Before(e.deadline)) {
+			due = append(due, *e)
+			continue
+		}
+		if t, ok := parseDate(e.charge.ExpireDate); ok && !now.Before(t) {
+			due = append(due, *e)
+		}
+	}
+	s.mu.Unlock()
+	p := s.p.WithContext(ctx)
+	for _, e := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		s.sweep(p, e, now)
+	}
+}
+
+func (s *Sweeper) sweep(p *Satis, e sweepEntry, now time.Time) {
+	c := e.charge
+	_, err := c.Refresh(p)
+	if err != nil {
+		s.report(SweepReport{Charge: c, Outcome: SweepError, Err: err})
+		return
+	}
+	outcome := ""
+	switch c.Status {
+	case Required:
+		if !e.abandoned && (e.deadline.IsZero() || now.Before(e.deadline)) {
+			//expired on Satispay's clock but not yet on the API, try later
+			s.update(c)
+			return
+		}
+		err = p.Charges.cancel(&c)
+		if err != nil {
+			s.update(c)
+			s.report(SweepReport{Charge: c, Outcome: SweepError, Err: err})
+			return
+		}
+		outcome = SweepCanceled
+	case Success:
+		outcome = SweepPaid
+	default:
+		switch c.StatusDetails {
+		case Canceled:
+			outcome = SweepCanceled
+		case ErrExpired:
+			outcome = SweepExpired
+		default:
+			outcome = SweepFailed
+		}
+	}
+	s.Untrack(c.ID)
+	s.report(SweepReport{Charge: c, Outcome: outcome})
+}
+
+//update stores the last known state of a charge still tracked
+func (s *Sweeper) update(c Charge) {
+	s.mu.Lock()
+	if e, ok := s.tracked[c.ID]; ok {
+		e.charge = c
+	}
+	s.mu.Unlock()
+}
+
+func (s *Sweeper) report(r SweepReport) {
+	if s.onReport != nil {
+		s.onReport(r)
+	}
+}
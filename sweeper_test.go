@@ -0,0 +1,127 @@
+package satisgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+func TestSweep(t *testing.T) {
+	var reports []satisgo.SweepReport
+	srv, p, u := newTestServer(t)
+	s := p.NewSweeper(satisgo.SweeperConfig{OnReport: func(r satisgo.SweepReport) { reports = append(reports, r) }})
+	past := time.Now().Add(-time.Minute)
+	abandoned := newTestCharge(t, srv, p, u, 100, satisgo.Required, nil)
+	late := newTestCharge(t, srv, p, u, 200, satisgo.Required, nil)
+	paid := newTestCharge(t, srv, p, u, 300, satisgo.Required, nil)
+	expired := newTestCharge(t, srv, p, u, 400, satisgo.Required, nil)
+	waiting := newTestCharge(t, srv, p, u, 500, satisgo.Required, nil)
+	for _, c := range []*satisgo.Charge{late, paid, expired} {
+		s.Track(c, past)
+	}
+	s.Track(abandoned, time.Now().Add(time.Hour))
+	s.Track(waiting, time.Now().Add(time.Hour))
+	s.Abandon(abandoned.ID)
+	srv.SetChargeStatus(paid.ID, satisgo.Success, "")
+	srv.SetChargeStatus(expired.ID, satisgo.Failure, satisgo.ErrExpired)
+
+	s.Sweep(context.Background())
+	want := map[string]string{
+		abandoned.ID: satisgo.SweepCanceled,
+		late.ID:      satisgo.SweepCanceled,
+		paid.ID:      satisgo.SweepPaid,
+		expired.ID:   satisgo.SweepExpired,
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("got %d reports, want %d: %+v", len(reports), len(want), reports)
+	}
+	for _, r := range reports {
+		if want[r.Charge.ID] != r.Outcome {
+			t.Errorf("charge %s: got %s, want %s", r.Charge.ID, r.Outcome, want[r.Charge.ID])
+		}
+	}
+	if n := s.Pending(); n != 1 {
+		t.Fatalf("got %d charges tracked, want 1", n)
+	}
+	for _, c := range srv.Charges() {
+		if c.ID == late.ID && c.StatusDetails != satisgo.Canceled {
+			t.Fatalf("charge past its deadline should be canceled on the server: %+v", c)
+		}
+	}
+}
+
+func TestSweeperRunCanceled(t *testing.T) {
+	//the charges cannot be read: the server waits for the client to give up
+	blocked := make(chan struct{}, 1)
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	u := srv.AddUser(testPhone)
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/charges/") {
+			blocked <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(front.Close)
+	p, err := satisgo.New(srv.Bearer, "staging", satisgo.WithBaseURL(front.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestCharge(t, srv, p, u, 100, satisgo.Required, nil)
+	s := p.NewSweeper(satisgo.SweeperConfig{Interval: time.Hour})
+	s.Track(c, time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	s.Abandon(c.ID)
+	<-blocked
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop the sweep in progress")
+	}
+}
+
+func TestSweeperRestart(t *testing.T) {
+	_, p, _ := newTestServer(t)
+	s := p.NewSweeper(satisgo.SweeperConfig{Interval: time.Hour})
+	for i := 0; i < 3; i++ {
+		done := make(chan error, 1)
+		go func() { done <- s.Run(context.Background()) }()
+		//Stop does nothing until Run has started
+		var stopped error
+		for running := true; running; {
+			var wg sync.WaitGroup
+			for j := 0; j < 2; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s.Stop()
+				}()
+			}
+			wg.Wait()
+			select {
+			case stopped = <-done:
+				running = false
+			case <-time.After(time.Millisecond):
+			}
+		}
+		if stopped != nil {
+			t.Fatalf("run %d: got %v", i, stopped)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package satisgo
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+//tlsConfig returns the TLS configuration being built by the options
+func (p *Satis) tlsConfig() *tls.Config {
+	if p.tls == nil {
+		p.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return p.tls
+}
+
+//WithRootCAs verifies the certificates of the API with pool instead of the system roots
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(p *Satis) error {
+		if pool == nil {
+			return fmt.Errorf("CertPool cannot be nil")
+		}
+		p.tlsConfig().RootCAs = pool
+		return nil
+	}
+}
+
+//WithRootCAsPEM adds the PEM encoded certificates to the roots trusted for the API
+func WithRootCAsPEM(pemCerts []byte) Option {
+	return func(p *Satis) error {
+		c := p.tlsConfig()
+		if c.RootCAs == nil {
+			c.RootCAs = x509.NewCertPool()
+		}
+		if !c.RootCAs.AppendCertsFromPEM(pemCerts) {
+			return fmt.Errorf("No valid certificate found in the PEM")
+		}
+		return nil
+	}
+}
+
+//WithPinnedKeys accepts only the API certificates whose chain contains one of the public keys.
+//A pin is the base64 SHA-256 of the SubjectPublicKeyInfo, with or without the "sha256/" prefix:
+//	openssl x509 -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//Give a pin of the next key as well before Satispay rotates its certificate.
+//The pins are checked on the verified chains: they cannot be used with WithInsecureSkipVerify
+func WithPinnedKeys(pins ...string) Option {
+	return func(p *Satis) error {
+		if len(p.pins) == 0 && len(pins) == 0 {
+			return fmt.Errorf("At least one pin is needed")
+		}
+		if p.tlsConfig().InsecureSkipVerify {
+			return fmt.Errorf("Pinned keys cannot be used with InsecureSkipVerify")
+		}
+		for _, pin := range pins {
+			b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+			if err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("Pin %s is not a base64 SHA-256", pin)
+			}
+			p.pins = append(p.pins, string(b))
+		}
+		p.tlsConfig().VerifyConnection = p.verifyPins
+		return nil
+	}
+}
+
+//verifyPins runs after the usual verification of the certificates
+//Only the verified chains are checked: the server can send any certificate along with them
+func (p *Satis) verifyPins(cs tls.ConnectionState) error {
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range p.pins {
+				if string(sum[:]) == pin {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("No pinned public key in the verified certificates of the API")
+}
+
+//WithClientCertificate authenticates the client with cert (mutual TLS)
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(p *Satis) error {
+		if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+			return fmt.Errorf("Client certificate needs a certificate and its private key")
+		}
+		c := p.tlsConfig()
+		c.Certificates = append(c.Certificates, cert)
+		return nil
+	}
+}
+
+//WithTLSMinVersion sets the lowest TLS version accepted (ex. tls.VersionTLS13), TLS 1.2 by default
+func WithTLSMinVersion(v uint16) Option {
+	return func(p *Satis) error {
+		if v < tls.VersionTLS12 || v > tls.VersionTLS13 {
+			return fmt.Errorf("TLS version %x is not allowed (only TLS 1.2 and 1.3)", v)
+		}
+		p.tlsConfig().MinVersion = v
+		return nil
+	}
+}
+
+//WithCipherSuites restricts the cipher suites of TLS 1.2 to the ones given (see tls.CipherSuites),
+//the cipher suites of TLS 1.3 are not configurable
+func WithCipherSuites(ids ...uint16) Option {
+	return func(p *Satis) error {
+		if len(ids) == 0 {
+			return fmt.Errorf("At least one cipher suite is needed")
+		}
+		secure := make(map[uint16]bool)
+		for _, s := range tls.CipherSuites() {
+			secure[s.ID] = true
+		}
+		for _, id := range ids {
+			if !secure[id] {
+				return fmt.Errorf("Cipher suite %s is not secure or not supported", tls.CipherSuiteName(id))
+			}
+		}
+		p.tlsConfig().CipherSuites = ids
+		return nil
+	}
+}
+
+//WithInsecureSkipVerify does not verify the certificates of the API.
+//It is meant for a staging environment with a self-signed certificate, never use it in production
+func WithInsecureSkipVerify() Option {
+	return func(p *Satis) error {
+		if p.env != dev {
+			return fmt.Errorf("InsecureSkipVerify is only allowed in staging")
+		}
+		if len(p.pins) > 0 {
+			return fmt.Errorf("InsecureSkipVerify cannot be used with pinned keys")
+		}
+		p.tlsConfig().InsecureSkipVerify = true
+		return nil
+	}
+}
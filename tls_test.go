@@ -0,0 +1,234 @@
+package satisgo_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+//newTLSServer serves the fake API over TLS, cfg changes the TLS configuration of the server
+func newTLSServer(t *testing.T, cfg func(*tls.Config)) (*satisgotest.Server, *httptest.Server) {
+	t.Helper()
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddUser(testPhone)
+	ts := httptest.NewUnstartedServer(srv.Config.Handler)
+	ts.TLS = new(tls.Config)
+	if cfg != nil {
+		cfg(ts.TLS)
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func tlsClient(t *testing.T, srv *satisgotest.Server, ts *httptest.Server, opts ...satisgo.Option) error {
+	t.Helper()
+	opts = append([]satisgo.Option{satisgo.WithBaseURL(ts.URL)}, opts...)
+	p, err := satisgo.New(srv.Bearer, "staging", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Verify()
+}
+
+func pin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+//selfSigned generates a certificate for client authentication
+func selfSigned(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "shop"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+func TestTLSRoots(t *testing.T) {
+	srv, ts := newTLSServer(t, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	_, other := selfSigned(t)
+
+	cases := []struct {
+		name string
+		opts []satisgo.Option
+		ok   bool
+	}{
+		{"system roots", nil, false},
+		{"root CAs", []satisgo.Option{satisgo.WithRootCAs(pool)}, true},
+		{"root CAs PEM", []satisgo.Option{satisgo.WithRootCAsPEM(certPEM)}, true},
+		{"pinned key", []satisgo.Option{satisgo.WithRootCAs(pool), satisgo.WithPinnedKeys(pin(other), pin(ts.Certificate()))}, true},
+		{"wrong pin", []satisgo.Option{satisgo.WithRootCAs(pool), satisgo.WithPinnedKeys(pin(other))}, false},
+		{"insecure", []satisgo.Option{satisgo.WithInsecureSkipVerify()}, true},
+		{"TLS 1.3 only", []satisgo.Option{satisgo.WithRootCAs(pool), satisgo.WithTLSMinVersion(tls.VersionTLS13)}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tlsClient(t, srv, ts, tc.opts...)
+			if tc.ok && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("the connection should be refused")
+			}
+		})
+	}
+}
+
+func TestTLSVersion(t *testing.T) {
+	srv, ts := newTLSServer(t, func(c *tls.Config) { c.MaxVersion = tls.VersionTLS12 })
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	if err := tlsClient(t, srv, ts, satisgo.WithRootCAs(pool)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tlsClient(t, srv, ts, satisgo.WithRootCAs(pool), satisgo.WithTLSMinVersion(tls.VersionTLS13)); err == nil {
+		t.Fatal("a TLS 1.2 server should be refused with TLS 1.3 as minimum")
+	}
+	suite := tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	if err := tlsClient(t, srv, ts, satisgo.WithRootCAs(pool), satisgo.WithCipherSuites(suite)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	cert, parsed := selfSigned(t)
+	clients := x509.NewCertPool()
+	clients.AddCert(parsed)
+	srv, ts := newTLSServer(t, func(c *tls.Config) {
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		c.ClientCAs = clients
+	})
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	if err := tlsClient(t, srv, ts, satisgo.WithRootCAs(pool)); err == nil {
+		t.Fatal("the server should refuse a client without certificate")
+	}
+	if err := tlsClient(t, srv, ts, satisgo.WithRootCAs(pool), satisgo.WithClientCertificate(cert)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//issue generates a CA and a certificate for 127.0.0.1 signed by it
+func issue(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caCert
+}
+
+func TestTLSPinnedKeyNotInChain(t *testing.T) {
+	leaf, caCert := issue(t)
+	_, pinned := selfSigned(t)
+	//a valid certificate of someone else, sent along with the pinned certificate
+	leaf.Certificate = append(leaf.Certificate, pinned.Raw)
+	srv, ts := newTLSServer(t, func(c *tls.Config) { c.Certificates = []tls.Certificate{leaf} })
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	err := tlsClient(t, srv, ts, satisgo.WithRootCAs(pool), satisgo.WithPinnedKeys(pin(pinned)))
+	if err == nil {
+		t.Fatal("a pinned key outside of the verified chain should be refused")
+	}
+	err = tlsClient(t, srv, ts, satisgo.WithRootCAs(pool), satisgo.WithPinnedKeys(pin(caCert)))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSOptions(t *testing.T) {
+	bad := map[string]satisgo.Option{
+		"insecure in production": satisgo.WithInsecureSkipVerify(),
+		"TLS 1.1":                satisgo.WithTLSMinVersion(tls.VersionTLS11),
+		"insecure cipher suite":  satisgo.WithCipherSuites(tls.TLS_RSA_WITH_RC4_128_SHA),
+		"malformed pin":          satisgo.WithPinnedKeys("sha256/short"),
+		"no pin":                 satisgo.WithPinnedKeys(),
+		"PEM without cert":       satisgo.WithRootCAsPEM([]byte("not a certificate")),
+		"no private key":         satisgo.WithClientCertificate(tls.Certificate{Certificate: [][]byte{{1}}}),
+	}
+	for name, opt := range bad {
+		if _, err := satisgo.New("token", "production", opt); err == nil {
+			t.Errorf("%s: the option should be refused", name)
+		}
+	}
+	_, other := selfSigned(t)
+	if _, err := satisgo.New("token", "staging", satisgo.WithPinnedKeys(pin(other)), satisgo.WithInsecureSkipVerify()); err == nil {
+		t.Error("pinned keys and then insecure: the options should be refused")
+	}
+	if _, err := satisgo.New("token", "staging", satisgo.WithInsecureSkipVerify(), satisgo.WithPinnedKeys(pin(other))); err == nil {
+		t.Error("insecure and then pinned keys: the options should be refused")
+	}
+}
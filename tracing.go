@@ -0,0 +1,120 @@
+package satisgo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//Attribute keys set on the spans
+const (
+	AttrEndpoint   = "satisgo.endpoint"
+	AttrChargeID   = "satisgo.charge_id"
+	AttrRefundID   = "satisgo.refund_id"
+	AttrUserID     = "satisgo.user_id"
+	AttrPhone      = "satisgo.phone_number"
+	AttrAmount     = "satisgo.amount"
+	AttrStatus     = "satisgo.status"
+	AttrDetails    = "satisgo.status_detail"
+	AttrRetries    = "satisgo.retries"
+	AttrCount      = "satisgo.count"
+	AttrHasMore    = "satisgo.has_more"
+	AttrFrom       = "satisgo.from"
+	AttrTo         = "satisgo.to"
+	AttrCache      = "satisgo.cache"
+	AttrMethod     = "http.method"
+	AttrStatusCode = "http.status_code"
+)
+
+//Attribute is a key value pair describing a span
+//Value is a string, an int64 or a bool
+type Attribute struct {
+	Key   string
+	Value interface{}
+	//PII marks personal data (phone numbers, user ids): tracers should drop it unless told otherwise
+	PII bool
+}
+
+//Tracer starts the spans of the operations of the client, see the satisotel package for OpenTelemetry
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+//Span is a single traced operation
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	//End closes the span, err is nil when the operation succeeded
+	End(err error)
+}
+
+//WithTracer traces every operation of the client, as children of the span in the context of the client
+//(see WithContext): each operation, each page of the listings and each chunk of the amounts has its span
+func WithTracer(t Tracer) Option {
+	return func(p *Satis) error {
+		if t == nil {
+			return fmt.Errorf("Tracer cannot be nil")
+		}
+		p.tracer = t
+		return nil
+	}
+}
+
+func attrString(k, v string) Attribute {
+	return Attribute{Key: k, Value: v}
+}
+
+func attrInt(k string, v int64) Attribute {
+	return Attribute{Key: k, Value: v}
+}
+
+func attrPII(k, v string) Attribute {
+	return Attribute{Key: k, Value: v, PII: true}
+}
+
+func attrTime(k string, t time.Time) Attribute {
+	return Attribute{Key: k, Value: t.Format(time.RFC3339)}
+}
+
+func chargeAttrs(c *Charge) []Attribute {
+	return []Attribute{
+		attrString(AttrChargeID, c.ID),
+		attrInt(AttrAmount, int64(c.Amount)),
+		attrString(AttrStatus, c.Status),
+		attrString(AttrDetails, c.StatusDetails),
+	}
+}
+
+func refundAttrs(r *Refund) []Attribute {
+	return []Attribute{
+		attrString(AttrRefundID, r.ID),
+		attrString(AttrChargeID, r.ChargeID),
+		attrInt(AttrAmount, int64(r.Amount)),
+	}
+}
+
+//span is the internal handle of a Span, it does nothing when no tracer is configured
+type span struct {
+	s Span
+}
+
+func (sp span) set(attrs ...Attribute) {
+	if sp.s != nil {
+		sp.s.SetAttributes(attrs...)
+	}
+}
+
+func (sp span) end(err *error) {
+	if sp.s != nil {
+		sp.s.End(*err)
+	}
+}
+
+//startSpan starts a span and returns a copy of the client bound to it
+func (p *Satis) startSpan(name string, attrs ...Attribute) (*Satis, span) {
+	if p.tracer == nil {
+		return p, span{}
+	}
+	ctx, s := p.tracer.Start(p.context(), "satisgo."+name)
+	s.SetAttributes(attrs...)
+	return p.WithContext(ctx), span{s}
+}
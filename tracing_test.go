@@ -0,0 +1,100 @@
+package satisgo_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+)
+
+//spanRecorder is a Tracer keeping the spans, the parent is the span in the context
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]satisgo.Attribute
+	ended  bool
+	err    error
+}
+
+type spanKey struct{}
+
+func (r *spanRecorder) Start(ctx context.Context, name string) (context.Context, satisgo.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	s := &recordedSpan{name: name, parent: parent, attrs: make(map[string]satisgo.Attribute)}
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (r *spanRecorder) named(name string) []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []*recordedSpan
+	for _, s := range r.spans {
+		if s.name == name {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...satisgo.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a
+	}
+}
+
+func (s *recordedSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+func TestTracer(t *testing.T) {
+	tr := new(spanRecorder)
+	_, p, _ := newTestServer(t, satisgo.WithTracer(tr))
+	ctx, root := tr.Start(context.Background(), "test")
+
+	_, err := p.WithContext(ctx).UserFromPhone(testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.WithContext(ctx).GetCharge("not-on-satispay")
+	if !satisgo.IsNotFound(err) {
+		t.Fatalf("got %v, want a not found error", err)
+	}
+
+	lookup := tr.named("satisgo.UserFromPhone")
+	if len(lookup) != 1 || lookup[0].parent != root || !lookup[0].ended || lookup[0].err != nil {
+		t.Fatalf("unexpected UserFromPhone spans %+v", lookup)
+	}
+	if a := lookup[0].attrs[satisgo.AttrPhone]; a.Value != testPhone || !a.PII {
+		t.Fatalf("the phone number should be an attribute marked as PII, got %+v", a)
+	}
+	get := tr.named("satisgo.GetCharge")
+	if len(get) != 1 || get[0].parent != root || !get[0].ended || !satisgo.IsNotFound(get[0].err) {
+		t.Fatalf("unexpected GetCharge spans %+v", get)
+	}
+	calls := tr.named("satisgo.call")
+	if len(calls) != 2 {
+		t.Fatalf("got %d call spans, want 2", len(calls))
+	}
+	for i, want := range []*recordedSpan{lookup[0], get[0]} {
+		c := calls[i]
+		if c.parent != want || !c.ended {
+			t.Errorf("call span %d should be an ended child of %s", i, want.name)
+		}
+		if c.attrs[satisgo.AttrEndpoint].Value == "" || c.attrs[satisgo.AttrStatusCode].Value == nil {
+			t.Errorf("call span %d misses the endpoint or the status code: %+v", i, c.attrs)
+		}
+	}
+	if code := calls[1].attrs[satisgo.AttrStatusCode].Value; code != int64(404) {
+		t.Fatalf("got status code %v, want 404", code)
+	}
+}
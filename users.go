@@ -1,9 +1,10 @@
 package satisgo
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/buger/jsonparser"
 )
@@ -15,15 +16,49 @@ type User struct {
 }
 
 //UserFromPhone is the way to get an identifier with a phone number
+//
+//Deprecated: use p.Users.Lookup
 func (p *Satis) UserFromPhone(phone string) (*User, error) {
-	reader := strings.NewReader(fmt.Sprintf(`{"phone_number":"%s"}`, phone))
-	r, err := http.NewRequest("POST", p.usersURL(), reader)
+	return p.Users.Lookup(phone)
+}
+
+//Lookup returns the user of a phone number, normalized with NormalizePhone in the region set by WithDefaultRegion.
+//With WithUserCache the answer may come from the cache, IsNotFound tells when nobody has Satispay on that number
+func (s *UserService) Lookup(phone string) (_ *User, err error) {
+	p, span := s.p.startSpan("UserFromPhone", attrPII(AttrPhone, phone))
+	defer span.end(&err)
+	phone, err = NormalizePhone(phone, p.region)
+	if err != nil {
+		return nil, err
+	}
+	u, ok, err := p.cachedUser("phone:"+phone, span)
+	if ok {
+		return u, err
+	}
+	u, err = p.userFromPhone(phone)
+	p.cacheUser("phone:"+phone, u, err)
+	if err != nil {
+		return nil, err
+	}
+	span.set(attrPII(AttrUserID, u.ID))
+	return u, nil
+}
+
+//userFromPhone calls the API with a normalized phone number
+func (p *Satis) userFromPhone(phone string) (*User, error) {
+	body, err := json.Marshal(struct {
+		Phone string `json:"phone_number"`
+	}{phone})
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequest("POST", p.usersURL(), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return nil, fmt.Errorf("Error making the call to API: %s", err.Error())
+		return nil, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return nil, fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -40,7 +75,8 @@ func (p *Satis) UserFromPhone(phone string) (*User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error Parsing Phone_number from body: %s", err.Error())
 	}
-	if phone != ph {
+	ph, err = NormalizePhone(ph, p.region)
+	if err != nil || phone != ph {
 		return nil, fmt.Errorf("the sent number phone is not the same when it came back")
 	}
 	if ud != id {
@@ -53,14 +89,33 @@ func (p *Satis) UserFromPhone(phone string) (*User, error) {
 }
 
 //UserFromID is the way to get a phone number with an id
+//
+//Deprecated: use p.Users.Get
 func (p *Satis) UserFromID(id string) (*User, error) {
+	return p.Users.Get(id)
+}
+
+//Get returns the user with the given id, it uses the cache as Lookup
+func (s *UserService) Get(id string) (_ *User, err error) {
+	p, span := s.p.startSpan("UserFromID", attrPII(AttrUserID, id))
+	defer span.end(&err)
+	u, ok, err := p.cachedUser("id:"+id, span)
+	if ok {
+		return u, err
+	}
+	u, err = p.userFromID(id)
+	p.cacheUser("id:"+id, u, err)
+	return u, err
+}
+
+func (p *Satis) userFromID(id string) (*User, error) {
 	r, err := http.NewRequest("GET", p.usersURL()+"/"+id, nil)
 	if err != nil {
 		return nil, err
 	}
 	status, b, err := p.makeCall(r)
 	if err != nil {
-		return nil, fmt.Errorf("Error making the call to API: %s", err.Error())
+		return nil, fmt.Errorf("Error making the call to API: %w", err)
 	}
 	if status != 200 {
 		return nil, fmt.Errorf("Return status is %d:not compatible with the success case", status)
@@ -1 +1,46 @@
 package satisgo_test
+
+import (
+	"testing"
+
+	"github.com/drymonsoon/satisgo"
+	"github.com/drymonsoon/satisgo/satisgotest"
+)
+
+const testPhone = "+393331234567"
+
+//newTestServer starts a fake API knowing a single user and returns a client for it
+func newTestServer(t *testing.T, opts ...satisgo.Option) (*satisgotest.Server, *satisgo.Satis, satisgo.User) {
+	t.Helper()
+	srv := satisgotest.NewServer()
+	t.Cleanup(srv.Close)
+	u := srv.AddUser(testPhone)
+	p, err := srv.Client(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, p, u
+}
+
+//newTestCharge creates a charge of amount EuroCents for u, status other than REQUIRED is set on the server
+func newTestCharge(t *testing.T, srv *satisgotest.Server, p *satisgo.Satis, u satisgo.User, amount uint64, status string, metadata map[string]string) *satisgo.Charge {
+	t.Helper()
+	c, err := p.Charges.Create(satisgo.ChargeCreateParams{
+		UserID:      u.ID,
+		Amount:      amount,
+		CallbackURL: "https://example.com/callback?charge_id={uuid}",
+		Metadata:    metadata,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status == satisgo.Required {
+		return c
+	}
+	srv.SetChargeStatus(c.ID, status, "")
+	c, err = p.Charges.Get(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
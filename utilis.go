@@ -19,6 +19,20 @@ func getTime(s string) *time.Time {
 	return &t
 }
 
+//parseDate reads the dates found in the API objects: ISO 8601 strings or UnixMilli
+func parseDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	if t := getTime(s); t != nil {
+		return *t, true
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, n*int64(time.Millisecond)), true
+	}
+	return time.Time{}, false
+}
+
 func putUnix(t time.Time) string {
 	n := t.UnixNano()
 	n = n / 1000000